@@ -0,0 +1,183 @@
+package asreval
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/width"
+)
+
+// Op 对齐操作
+type Op int
+
+const (
+	OpEqual      Op = iota // 正确
+	OpSubstitute           // 替换
+	OpDelete               // 删除，参考文本中有而识别结果中没有
+	OpInsert               // 插入，识别结果中多出的内容
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpSubstitute:
+		return "S"
+	case OpDelete:
+		return "D"
+	case OpInsert:
+		return "I"
+	}
+	return "C"
+}
+
+// Edit 一个对齐位置，删除时Hyp为空，插入时Ref为空
+type Edit struct {
+	Op  Op
+	Ref string
+	Hyp string
+}
+
+// Counts 错误统计
+type Counts struct {
+	Ref           int `json:"ref"` // 参考文本的token数
+	Hits          int `json:"hits"`
+	Substitutions int `json:"substitutions"`
+	Deletions     int `json:"deletions"`
+	Insertions    int `json:"insertions"`
+}
+
+// Errors 返回替换、删除和插入的总数
+func (c Counts) Errors() int {
+	return c.Substitutions + c.Deletions + c.Insertions
+}
+
+// Rate 返回错误率，参考文本为空时有任何插入即为1
+func (c Counts) Rate() float64 {
+	if c.Ref == 0 {
+		if c.Insertions > 0 {
+			return 1
+		}
+		return 0
+	}
+	return float64(c.Errors()) / float64(c.Ref)
+}
+
+// Add 累加另一组统计
+func (c *Counts) Add(o Counts) {
+	c.Ref += o.Ref
+	c.Hits += o.Hits
+	c.Substitutions += o.Substitutions
+	c.Deletions += o.Deletions
+	c.Insertions += o.Insertions
+}
+
+// Alignment 参考文本与识别结果的最小编辑距离对齐
+type Alignment struct {
+	Counts
+	Edits []Edit
+}
+
+// Align 计算ref到hyp的最小编辑距离对齐，代价相同时依次优先正确/替换、删除、插入
+func Align(ref, hyp []string) *Alignment {
+	n, m := len(ref), len(hyp)
+	// dist[i][j] 为ref[:i]与hyp[:j]的编辑距离
+	dist := make([][]int, n+1)
+	for i := range dist {
+		dist[i] = make([]int, m+1)
+		dist[i][0] = i
+	}
+	for j := 0; j <= m; j++ {
+		dist[0][j] = j
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			diag := dist[i-1][j-1]
+			if ref[i-1] != hyp[j-1] {
+				diag++
+			}
+			dist[i][j] = min(diag, dist[i-1][j]+1, dist[i][j-1]+1)
+		}
+	}
+
+	a := &Alignment{Counts: Counts{Ref: n}}
+	for i, j := n, m; i > 0 || j > 0; {
+		switch {
+		case i > 0 && j > 0 && ref[i-1] == hyp[j-1] && dist[i][j] == dist[i-1][j-1]:
+			a.Edits = append(a.Edits, Edit{Op: OpEqual, Ref: ref[i-1], Hyp: hyp[j-1]})
+			a.Hits++
+			i, j = i-1, j-1
+		case i > 0 && j > 0 && dist[i][j] == dist[i-1][j-1]+1:
+			a.Edits = append(a.Edits, Edit{Op: OpSubstitute, Ref: ref[i-1], Hyp: hyp[j-1]})
+			a.Substitutions++
+			i, j = i-1, j-1
+		case i > 0 && dist[i][j] == dist[i-1][j]+1:
+			a.Edits = append(a.Edits, Edit{Op: OpDelete, Ref: ref[i-1]})
+			a.Deletions++
+			i--
+		default:
+			a.Edits = append(a.Edits, Edit{Op: OpInsert, Hyp: hyp[j-1]})
+			a.Insertions++
+			j--
+		}
+	}
+	for l, r := 0, len(a.Edits)-1; l < r; l, r = l+1, r-1 {
+		a.Edits[l], a.Edits[r] = a.Edits[r], a.Edits[l]
+	}
+	return a
+}
+
+// displayWidth 返回终端显示宽度，中文等宽字符占两列
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			w += 2
+		default:
+			w++
+		}
+	}
+	return w
+}
+
+// pad 用空格将s补齐到n列
+func pad(s string, n int) string {
+	return s + strings.Repeat(" ", n-displayWidth(s))
+}
+
+// Write 以三行对齐的形式输出，空位用*表示，如
+//
+//	REF: 今 天 天 气 *
+//	HYP: 今 天 田 气 好
+//	     C  C  S  C  I
+func (a *Alignment) Write(w io.Writer) error {
+	var ref, hyp, ops []string
+	for _, e := range a.Edits {
+		r, h := e.Ref, e.Hyp
+		if e.Op == OpDelete {
+			h = "*"
+		}
+		if e.Op == OpInsert {
+			r = "*"
+		}
+		n := max(displayWidth(r), displayWidth(h), 1)
+		ref = append(ref, pad(r, n))
+		hyp = append(hyp, pad(h, n))
+		ops = append(ops, pad(e.Op.String(), n))
+	}
+	_, err := fmt.Fprintf(w, "REF: %s\nHYP: %s\n     %s\n",
+		strings.TrimRight(strings.Join(ref, " "), " "),
+		strings.TrimRight(strings.Join(hyp, " "), " "),
+		strings.TrimRight(strings.Join(ops, " "), " "))
+	if err != nil {
+		return fmt.Errorf("write alignment failed: %v", err)
+	}
+	return nil
+}
+
+// String 返回Write的输出
+func (a *Alignment) String() string {
+	var b strings.Builder
+	a.Write(&b)
+	return b.String()
+}
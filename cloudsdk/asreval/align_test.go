@@ -0,0 +1,35 @@
+package asreval
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlign(t *testing.T) {
+	a := Align(Chars("今天天气很好"), Chars("今天田气好"))
+	assert.Equal(t, Counts{Ref: 6, Hits: 4, Substitutions: 1, Deletions: 1}, a.Counts)
+	assert.InDelta(t, 2.0/6, a.Rate(), 1e-9)
+	assert.Equal(t, "REF: 今 天 天 气 很 好\n"+
+		"HYP: 今 天 田 气 *  好\n"+
+		"     C  C  S  C  D  C\n", a.String())
+
+	a = Align(Words("turn on the light"), Words("turn on a the lights"))
+	var ops []Op
+	for _, e := range a.Edits {
+		ops = append(ops, e.Op)
+	}
+	assert.Equal(t, []Op{OpEqual, OpEqual, OpInsert, OpEqual, OpSubstitute}, ops)
+	assert.Equal(t, "REF: turn on * the light\n"+
+		"HYP: turn on a the lights\n"+
+		"     C    C  I C   S\n", a.String())
+}
+
+func TestAlign_Empty(t *testing.T) {
+	assert.Equal(t, 0.0, Align(nil, nil).Rate())
+	assert.Equal(t, 1.0, Align(nil, []string{"a"}).Rate())
+
+	a := Align([]string{"a", "b"}, nil)
+	assert.Equal(t, 2, a.Deletions)
+	assert.Equal(t, 1.0, a.Rate())
+}
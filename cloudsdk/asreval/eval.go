@@ -0,0 +1,93 @@
+package asreval
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shikanon/myapi/cloudsdk"
+)
+
+// ItemResult 单条音频的评估结果
+type ItemResult struct {
+	Item
+	Hypothesis string        `json:"hypothesis"`
+	CER        *Alignment    `json:"-"`
+	WER        *Alignment    `json:"-"`
+	Latency    time.Duration `json:"-"`
+	Err        string        `json:"error,omitempty"` // 识别失败时的错误，不计入错误率
+}
+
+// Evaluator 在评估清单上运行识别器并统计错误率
+type Evaluator struct {
+	Recognizer  cloudsdk.Recognizer
+	Concurrency int // 并发识别数，默认4
+	Normalize   NormalizeOptions
+}
+
+// audioFormat 按扩展名推断音频格式，无法识别时留空使用识别器的默认配置
+func audioFormat(path string) string {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case "wav", "mp3", "ogg", "pcm", "pcmu", "pcma":
+		return ext
+	case "opus":
+		return "ogg"
+	}
+	return ""
+}
+
+// Score 归一化后计算一条识别结果的CER和WER对齐
+func Score(ref, hyp string, opts NormalizeOptions) (cer, wer *Alignment) {
+	ref, hyp = Normalize(ref, opts), Normalize(hyp, opts)
+	return Align(Chars(ref), Chars(hyp)), Align(Words(ref), Words(hyp))
+}
+
+// Run 识别清单中的全部音频并生成报告，单条识别失败记录在结果中而不中断评估
+// 只有ctx取消时返回错误
+func (e *Evaluator) Run(ctx context.Context, items []Item) (*Report, error) {
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	results := make([]*ItemResult, len(items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = e.evaluate(ctx, items[i])
+		}(i)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return newReport(results), nil
+}
+
+// evaluate 识别并评估一条音频
+func (e *Evaluator) evaluate(ctx context.Context, item Item) *ItemResult {
+	r := &ItemResult{Item: item}
+	start := time.Now()
+	result, err := e.Recognizer.Recognize(ctx, &cloudsdk.RecognizeRequest{AudioPath: item.Audio, Format: audioFormat(item.Audio)})
+	r.Latency = time.Since(start)
+	if err != nil {
+		r.Err = err.Error()
+		return r
+	}
+	r.Hypothesis = result.Text
+	r.CER, r.WER = Score(item.Text, r.Hypothesis, e.Normalize)
+	return r
+}
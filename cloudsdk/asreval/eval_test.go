@@ -0,0 +1,70 @@
+package asreval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/shikanon/myapi/cloudsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRecognizer 按音频路径返回预设的识别结果
+type fakeRecognizer struct {
+	cloudsdk.Recognizer
+	texts   map[string]string
+	formats map[string]string
+}
+
+func (f *fakeRecognizer) Recognize(ctx context.Context, req *cloudsdk.RecognizeRequest) (*cloudsdk.RecognizeResult, error) {
+	text, ok := f.texts[req.AudioPath]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	return &cloudsdk.RecognizeResult{Text: text, IsFinal: true}, nil
+}
+
+func TestEvaluator_Run(t *testing.T) {
+	recognizer := &fakeRecognizer{texts: map[string]string{
+		"a.wav":  "今天天气很好。",
+		"b.opus": "打开客厅的灯",
+	}}
+	items := []Item{
+		{ID: "a", Audio: "a.wav", Text: "今天天气很好"},
+		{ID: "b", Audio: "b.opus", Text: "打开卧室的灯"},
+		{ID: "c", Audio: "c.wav", Text: "关闭空调"},
+	}
+	report, err := (&Evaluator{Recognizer: recognizer}).Run(context.Background(), items)
+	require.NoError(t, err)
+
+	require.Len(t, report.Items, 3)
+	assert.Equal(t, 0.0, report.Items[0].CER.Rate())
+	assert.Equal(t, 2, report.Items[1].CER.Substitutions)
+	assert.Equal(t, "connection refused", report.Items[2].Err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, Counts{Ref: 12, Hits: 10, Substitutions: 2}, report.CER)
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text, true))
+	assert.Contains(t, text.String(), "b\tCER 33.33%\tWER 33.33%\nREF: 打 开 卧 室 的 灯\n")
+	assert.Contains(t, text.String(), "c\tERROR\tconnection refused\n")
+	assert.Contains(t, text.String(), "CER 16.67% [N=12, S=2, D=0, I=0]")
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteJSON(&out))
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.InDelta(t, 2.0/12, decoded["cer_rate"], 1e-9)
+	first := decoded["items"].([]interface{})[1].(map[string]interface{})
+	assert.Equal(t, "打开客厅的灯", first["hypothesis"])
+	assert.Equal(t, float64(2), first["cer"].(map[string]interface{})["substitutions"])
+}
+
+func TestAudioFormat(t *testing.T) {
+	assert.Equal(t, "wav", audioFormat("x/A.WAV"))
+	assert.Equal(t, "ogg", audioFormat("a.opus"))
+	assert.Equal(t, "", audioFormat("a.flac"))
+}
@@ -0,0 +1,77 @@
+package asreval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Item 评估清单中的一条音频和参考文本
+type Item struct {
+	ID    string `json:"id"`
+	Audio string `json:"audio"`
+	Text  string `json:"text"`
+}
+
+// LoadManifest 读取评估清单
+// 每行一条，可以是{"id","audio","text"}格式的JSON，也可以是"音频路径<TAB>参考文本"或"ID<TAB>音频路径<TAB>参考文本"，
+// 空行和#开头的行被忽略，相对路径相对于清单文件所在目录
+func LoadManifest(path string) ([]Item, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %v", err)
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(path)
+	var items []Item
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		item, err := parseManifestLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("manifest line %d: %v", n, err)
+		}
+		if item.ID == "" {
+			item.ID = strings.TrimSuffix(filepath.Base(item.Audio), filepath.Ext(item.Audio))
+		}
+		if !filepath.IsAbs(item.Audio) {
+			item.Audio = filepath.Join(dir, item.Audio)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	return items, nil
+}
+
+func parseManifestLine(line string) (Item, error) {
+	var item Item
+	if strings.HasPrefix(line, "{") {
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			return item, fmt.Errorf("invalid json: %v", err)
+		}
+	} else {
+		fields := strings.Split(line, "\t")
+		switch len(fields) {
+		case 2:
+			item.Audio, item.Text = fields[0], fields[1]
+		case 3:
+			item.ID, item.Audio, item.Text = fields[0], fields[1], fields[2]
+		default:
+			return item, fmt.Errorf("expected 2 or 3 tab-separated fields, got %d", len(fields))
+		}
+	}
+	if item.Audio == "" {
+		return item, fmt.Errorf("missing audio path")
+	}
+	return item, nil
+}
@@ -0,0 +1,32 @@
+package asreval
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.txt")
+	content := "# 注释\n" +
+		`{"id": "a", "audio": "/data/a.wav", "text": "你好"}` + "\n\n" +
+		"audio/b.mp3\t今天天气\n" +
+		"c\taudio/c.wav\t打开空调\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	items, err := LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Item{
+		{ID: "a", Audio: "/data/a.wav", Text: "你好"},
+		{ID: "b", Audio: filepath.Join(dir, "audio/b.mp3"), Text: "今天天气"},
+		{ID: "c", Audio: filepath.Join(dir, "audio/c.wav"), Text: "打开空调"},
+	}, items)
+
+	require.NoError(t, os.WriteFile(path, []byte("a.wav\n"), 0o644))
+	_, err = LoadManifest(path)
+	assert.ErrorContains(t, err, "line 1")
+}
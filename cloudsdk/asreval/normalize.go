@@ -0,0 +1,86 @@
+// Package asreval 评估语音识别的准确率
+// 对识别结果和参考文本做归一化和中文感知的分词，计算CER/WER并输出对齐详情
+package asreval
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeOptions 文本归一化参数，零值为默认的归一化方式
+type NormalizeOptions struct {
+	KeepPunctuation bool // 保留标点符号，默认去掉
+	KeepCase        bool // 保留大小写，默认统一为小写
+}
+
+// Normalize 归一化文本：全角转半角、去掉标点、统一小写并合并空白
+// 识别结果中的标点由服务端添加，通常不计入错误率
+func Normalize(s string, opts NormalizeOptions) string {
+	s = norm.NFKC.String(s)
+	if !opts.KeepCase {
+		s = strings.ToLower(s)
+	}
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case !opts.KeepPunctuation && r != '\'' && (unicode.IsPunct(r) || unicode.IsSymbol(r)):
+			// 标点按空白处理，避免"hello,world"粘连成一个词；撇号属于单词的一部分
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isCJK 判断是否为按单字计分的中日韩文字
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Chars 按字符切分，忽略空白，用于计算CER
+func Chars(s string) []string {
+	var tokens []string
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			tokens = append(tokens, string(r))
+		}
+	}
+	return tokens
+}
+
+// Words 按词切分，用于计算WER
+// 中日韩文字没有空格分词，每个字作为一个词；其他文字按空白和文字类别切分
+func Words(s string) []string {
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case isCJK(r):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,19 @@
+package asreval
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "今天天气 怎么样 hello world 123", Normalize("今天天气，怎么样？ＨＥＬＬＯ,World！１２３", NormalizeOptions{}))
+	assert.Equal(t, "don't stop", Normalize("Don't   stop.", NormalizeOptions{}))
+	assert.Equal(t, "Hi,你好。", Normalize("Hi，你好。", NormalizeOptions{KeepPunctuation: true, KeepCase: true}))
+}
+
+func TestTokenize(t *testing.T) {
+	s := Normalize("打开 WiFi 设置，音量调到50", NormalizeOptions{})
+	assert.Equal(t, []string{"打", "开", "w", "i", "f", "i", "设", "置", "音", "量", "调", "到", "5", "0"}, Chars(s))
+	assert.Equal(t, []string{"打", "开", "wifi", "设", "置", "音", "量", "调", "到", "50"}, Words(s))
+}
@@ -0,0 +1,90 @@
+package asreval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Report 评估报告
+type Report struct {
+	Items  []*ItemResult `json:"items"`
+	CER    Counts        `json:"cer"` // 所有成功识别条目的字符级统计
+	WER    Counts        `json:"wer"` // 所有成功识别条目的词级统计
+	Failed int           `json:"failed"`
+}
+
+func newReport(results []*ItemResult) *Report {
+	r := &Report{Items: results}
+	for _, item := range results {
+		if item.Err != "" {
+			r.Failed++
+			continue
+		}
+		r.CER.Add(item.CER.Counts)
+		r.WER.Add(item.WER.Counts)
+	}
+	return r
+}
+
+// WriteText 输出可读的报告，verbose为true时输出每条的对齐详情
+func (r *Report) WriteText(w io.Writer, verbose bool) error {
+	for _, item := range r.Items {
+		var err error
+		if item.Err != "" {
+			_, err = fmt.Fprintf(w, "%s\tERROR\t%s\n", item.ID, item.Err)
+		} else {
+			_, err = fmt.Fprintf(w, "%s\tCER %.2f%%\tWER %.2f%%\n", item.ID, 100*item.CER.Rate(), 100*item.WER.Rate())
+			if err == nil && verbose && item.CER.Errors() > 0 {
+				err = item.CER.Write(w)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("write report failed: %v", err)
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%s\n%s\nitems: %d, failed: %d\n",
+		formatCounts("CER", r.CER), formatCounts("WER", r.WER), len(r.Items), r.Failed)
+	if err != nil {
+		return fmt.Errorf("write report failed: %v", err)
+	}
+	return nil
+}
+
+func formatCounts(name string, c Counts) string {
+	return fmt.Sprintf("%s %.2f%% [N=%d, S=%d, D=%d, I=%d]", name, 100*c.Rate(), c.Ref, c.Substitutions, c.Deletions, c.Insertions)
+}
+
+// reportItem JSON报告中的单条结果
+type reportItem struct {
+	*ItemResult
+	Latency float64 `json:"latency"` // 秒
+	CER     *Counts `json:"cer,omitempty"`
+	WER     *Counts `json:"wer,omitempty"`
+}
+
+// WriteJSON 输出JSON格式的报告，便于比较不同配置的评估结果
+func (r *Report) WriteJSON(w io.Writer) error {
+	items := make([]reportItem, len(r.Items))
+	for i, item := range r.Items {
+		items[i] = reportItem{ItemResult: item, Latency: item.Latency.Seconds()}
+		if item.Err == "" {
+			items[i].CER, items[i].WER = &item.CER.Counts, &item.WER.Counts
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(struct {
+		Items   []reportItem `json:"items"`
+		CER     Counts       `json:"cer"`
+		CERRate float64      `json:"cer_rate"`
+		WER     Counts       `json:"wer"`
+		WERRate float64      `json:"wer_rate"`
+		Failed  int          `json:"failed"`
+	}{items, r.CER, r.CER.Rate(), r.WER, r.WER.Rate(), r.Failed})
+	if err != nil {
+		return fmt.Errorf("write report failed: %v", err)
+	}
+	return nil
+}
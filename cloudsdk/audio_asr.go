@@ -3,11 +3,14 @@ package cloudsdk
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
 	"time"
 
@@ -41,21 +44,35 @@ const (
 	GZIP_COMPRESSION = 0x01
 )
 
+// bigmodel流式识别要求的pcm格式，其他采样率、声道数和位深的输入会先转换
+const (
+	asrSampleRate = 16000
+	asrChannels   = 1
+)
+
 type AsrConfig struct {
 	SegDuration int
 	WsURL       string
 	UID         string
-	Format      string
+	Format      string // wav/pcm/mp3/ogg，pcmu/pcma为8kHz G.711，会先解码并上采样
 	Rate        int
 	Bits        int
 	Channel     int
 	Codec       string
 	AuthMethod  string
-	HotWords    string
+	HotWords    string // 逗号或换行分隔的热词
 	Streaming   bool
-	Mp3SegSize  int
+	Mp3SegSize  int    // mp3按SegDuration时长分包，SegDuration为0时才按该字节数分包
 	AccessKey   string // Add this
 	AppKey      string // Add this
+
+	Float             bool     // pcm采样为IEEE浮点数，Bits为32或64
+	HotWordList       []string // 直传热词，与HotWords合并去重
+	BoostingTableID   string   // 控制台创建的热词表ID
+	BoostingTableName string   // 控制台创建的热词表名称
+	Context           []string // 上下文文本，如对话历史，用于提升相关内容的识别准确率
+
+	Options AsrRequestOptions // 识别请求参数，零值为默认行为
 }
 
 type AsrWsClient struct {
@@ -72,11 +89,9 @@ type Request struct {
 		Bits       int    `json:"bits"`
 		Channel    int    `json:"channel"`
 		Codec      string `json:"codec"`
+		Language   string `json:"language,omitempty"`
 	} `json:"audio"`
-	Request struct {
-		ModelName  string `json:"model_name"`
-		EnablePunc bool   `json:"enable_punc"`
-	} `json:"request"`
+	Request asrRequestParams `json:"request"`
 }
 
 type Response struct {
@@ -86,6 +101,7 @@ type Response struct {
 	Code            int
 	PayloadMsg      interface{}
 	PayloadSize     int
+	Result          *AsrResult // 识别结果，只有JSON格式的服务端完整响应才有
 }
 
 func NewAsrWsClient(config *AsrConfig) *AsrWsClient {
@@ -174,6 +190,9 @@ func parseResponse(res []byte) *Response {
 			if err := json.Unmarshal(payloadMsg, &msg); err == nil {
 				result.PayloadMsg = msg
 			}
+			if messageType == FULL_SERVER_RESPONSE {
+				result.Result, _ = parseAsrResult(payloadMsg)
+			}
 		} else if serializationMethod != NO_SERIALIZATION {
 			result.PayloadMsg = string(payloadMsg)
 		}
@@ -192,42 +211,101 @@ func (c *AsrWsClient) constructRequest(reqID string) *Request {
 	req.Audio.Bits = c.config.Bits
 	req.Audio.Channel = c.config.Channel
 	req.Audio.Codec = c.config.Codec
-	req.Request.ModelName = "bigmodel"
-	req.Request.EnablePunc = true
+	req.Audio.Language = c.config.Options.Language
+	req.Request = c.config.Options.params()
+	req.Request.Corpus = c.corpus()
 	return req
 }
 
-func (c *AsrWsClient) sliceData(data []byte, chunkSize int) <-chan struct {
-	Chunk []byte
-	Last  bool
-} {
-	ch := make(chan struct {
-		Chunk []byte
-		Last  bool
-	})
+// audioChunk 一个待发送的音频分包，Err不为空表示读取音频失败
+type audioChunk struct {
+	Chunk    []byte
+	Duration time.Duration // 分包的播放时长，未知时为0，按SegDuration控制节奏
+	Last     bool
+	Err      error
+}
+
+// chunkSource 按分包规则读出下一包及其时长，最后一包返回io.EOF，此时可能同时返回数据
+type chunkSource func() ([]byte, time.Duration, error)
+
+// fixedChunks 按固定字节数分包，bytesPerSecond大于0时按字节数计算每包的时长
+func fixedChunks(r io.Reader, chunkSize, bytesPerSecond int) chunkSource {
+	duration := func(n int) time.Duration {
+		if bytesPerSecond <= 0 {
+			return 0
+		}
+		return time.Duration(n) * time.Second / time.Duration(bytesPerSecond)
+	}
+	return func() ([]byte, time.Duration, error) {
+		buf := make([]byte, chunkSize)
+		n, err := io.ReadFull(r, buf)
+		switch err {
+		case nil:
+			return buf, duration(n), nil
+		case io.EOF, io.ErrUnexpectedEOF:
+			return buf[:n], duration(n), io.EOF
+		}
+		return nil, 0, err
+	}
+}
+
+// mp3Chunks 按完整的MPEG帧分包，每包时长达到segDuration时发出；segDuration为0时按segSize字节数凑包
+func mp3Chunks(r io.Reader, segDuration time.Duration, segSize int) chunkSource {
+	frames := newMP3FrameReader(r)
+	return func() ([]byte, time.Duration, error) {
+		var chunk []byte
+		var samples, rate int
+		for {
+			h, frame, err := frames.next()
+			if err != nil {
+				return chunk, samplesDuration(samples, rate), err
+			}
+			chunk = append(chunk, frame...)
+			samples, rate = samples+h.Samples, h.SampleRate
+			d := samplesDuration(samples, rate)
+			if segDuration > 0 && d >= segDuration || segDuration <= 0 && len(chunk) >= segSize {
+				return chunk, d, nil
+			}
+		}
+	}
+}
+
+func samplesDuration(samples, rate int) time.Duration {
+	if rate == 0 {
+		return 0
+	}
+	return time.Duration(samples) * time.Second / time.Duration(rate)
+}
+
+// streamChunks 在后台goroutine中从next读取分包并发送到channel
+func streamChunks(ctx context.Context, next chunkSource) <-chan audioChunk {
+	ch := make(chan audioChunk)
 
 	go func() {
 		defer close(ch)
-		dataLen := len(data)
-		offset := 0
-
-		for offset+chunkSize < dataLen {
-			ch <- struct {
-				Chunk []byte
-				Last  bool
-			}{
-				Chunk: data[offset : offset+chunkSize],
-				Last:  false,
+		send := func(c audioChunk) bool {
+			select {
+			case ch <- c:
+				return true
+			case <-ctx.Done():
+				return false
 			}
-			offset += chunkSize
 		}
 
-		ch <- struct {
-			Chunk []byte
-			Last  bool
-		}{
-			Chunk: data[offset:dataLen],
-			Last:  true,
+		for {
+			chunk, d, err := next()
+			switch err {
+			case nil:
+				if !send(audioChunk{Chunk: chunk, Duration: d}) {
+					return
+				}
+			case io.EOF:
+				send(audioChunk{Chunk: chunk, Duration: d, Last: true})
+				return
+			default:
+				send(audioChunk{Err: fmt.Errorf("failed to read audio: %v", err)})
+				return
+			}
 		}
 	}()
 
@@ -235,29 +313,204 @@ func (c *AsrWsClient) sliceData(data []byte, chunkSize int) <-chan struct {
 }
 
 func (c *AsrWsClient) RecognizeStream(audioPath string) (*Response, error) {
-	data, err := os.ReadFile(audioPath)
+	f, err := os.Open(audioPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read audio file: %v", err)
 	}
+	defer f.Close()
+
+	return c.RecognizeReader(context.Background(), f)
+}
+
+// RecognizeReader 从r中边读边识别，适用于管道、网络连接、标准输入等流式来源
+// r读到EOF时发送负序号的最后一包并返回最终结果
+func (c *AsrWsClient) RecognizeReader(ctx context.Context, r io.Reader) (*Response, error) {
+	return c.recognizeReader(ctx, r, nil)
+}
+
+func (c *AsrWsClient) recognizeReader(ctx context.Context, r io.Reader, onResponse func(*Response)) (*Response, error) {
+	if law, ok := g711Law(c.config.Format); ok {
+		c, r = c.withG711(r, law, G711SampleRate, c.config.Channel)
+	}
+	if c.config.Format == "wav" {
+		wc, data, err := c.withWAVHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		c, r = wc, data
+	}
+	if c.config.Format == "pcm" {
+		wc, data, err := c.withConversion(r)
+		if err != nil {
+			return nil, err
+		}
+		c, r = wc, data
+	}
+
+	if err := c.config.validateCorpus(); err != nil {
+		return nil, invalidRequest(err)
+	}
+	if err := c.config.Options.validate(); err != nil {
+		return nil, invalidRequest(err)
+	}
+	var source chunkSource
+	if c.config.Format == "ogg" {
+		// 需要先读出OpusHead才能确定请求中的声道数
+		wc, ogg, err := c.withOggOpus(r)
+		if err != nil {
+			return nil, err
+		}
+		c, source = wc, ogg
+	} else {
+		s, err := c.chunkSource(r)
+		if err != nil {
+			return nil, invalidRequest(err)
+		}
+		source = s
+	}
+
+	chunkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	resp, err := c.processData(chunkCtx, streamChunks(chunkCtx, source), onResponse)
+	if err != nil && ctx.Err() != nil {
+		// 取消导致的连接错误统一返回ctx的错误
+		return nil, ctx.Err()
+	}
+	return resp, err
+}
+
+// withWAVHeader 解析r开头的WAV头，返回按文件格式填充了Rate/Bits/Channel的pcm客户端副本和去掉文件头的音频流
+// G.711编码的WAV先解码为16位PCM
+func (c *AsrWsClient) withWAVHeader(r io.Reader) (*AsrWsClient, io.Reader, error) {
+	format, err := ReadWAVHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if law, ok := format.g711Law(); ok {
+		if format.Bits != 8 {
+			return nil, nil, fmt.Errorf("invalid %s wav: %d bits per sample", wavFormatName(format.FormatTag), format.Bits)
+		}
+		wc, data := c.withG711(format.wavData(r), law, format.SampleRate, format.Channels)
+		return wc, data, nil
+	}
+	pcm, err := format.PCMFormat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := *c.config
+	config.Format = "pcm"
+	config.Rate = pcm.SampleRate
+	config.Bits = pcm.Bits
+	config.Channel = pcm.Channels
+	config.Float = pcm.Float
+	wc := *c
+	wc.config = &config
+	return &wc, format.wavData(r), nil
+}
+
+// withConversion 输入PCM与服务端要求的16kHz单声道16位格式不同时转换音频流，
+// 返回的客户端副本总是填好服务端格式；配置中未设置的采样率、位深和声道数视为与服务端要求一致
+func (c *AsrWsClient) withConversion(r io.Reader) (*AsrWsClient, io.Reader, error) {
+	in := c.config.pcmFormat()
+	if !in.is16Bit(asrSampleRate, asrChannels) {
+		converter, err := NewPCMConverter(r, in, asrSampleRate, asrChannels)
+		if err != nil {
+			return nil, nil, err
+		}
+		r = converter
+	}
+
+	config := *c.config
+	config.Rate = asrSampleRate
+	config.Bits = 16
+	config.Channel = asrChannels
+	config.Float = false
+	wc := *c
+	wc.config = &config
+	return &wc, r, nil
+}
+
+// pcmFormat 返回配置描述的输入PCM格式，未设置的采样率、位深和声道数按服务端要求填充
+func (c *AsrConfig) pcmFormat() PCMFormat {
+	in := PCMFormat{SampleRate: c.Rate, Channels: c.Channel, Bits: c.Bits, Float: c.Float}
+	if in.SampleRate <= 0 {
+		in.SampleRate = asrSampleRate
+	}
+	if in.Channels <= 0 {
+		in.Channels = asrChannels
+	}
+	if in.Bits <= 0 {
+		in.Bits = 16
+	}
+	return in
+}
+
+// withPCMInput 返回输入为rate采样率、channels声道16位PCM的客户端副本，之后由withConversion转换为服务端要求的格式
+func (c *AsrWsClient) withPCMInput(rate, channels int) *AsrWsClient {
+	config := *c.config
+	config.Format = "pcm"
+	config.Rate = rate
+	config.Bits = 16
+	config.Channel = channels
+	config.Float = false
+	wc := *c
+	wc.config = &config
+	return &wc
+}
+
+// withG711 返回解码G.711后的pcm客户端副本，多声道数据按交织顺序逐字节解码，由withConversion混为单声道并上采样
+func (c *AsrWsClient) withG711(r io.Reader, law G711Law, rate, channels int) (*AsrWsClient, io.Reader) {
+	if channels <= 0 {
+		channels = 1
+	}
+	return c.withPCMInput(rate, channels), NewG711Decoder(r, law)
+}
+
+// withOggOpus 读取Ogg/Opus文件头，返回设置了opus编码和声道数的客户端副本，以及按整页分包的音频来源
+func (c *AsrWsClient) withOggOpus(r io.Reader) (*AsrWsClient, chunkSource, error) {
+	ogg, err := NewOggOpusReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := *c.config
+	config.Codec = "opus"
+	config.Channel = ogg.Head.Channels
+	if config.Rate <= 0 {
+		config.Rate = asrSampleRate
+	}
+	if config.Bits <= 0 {
+		config.Bits = 16
+	}
+	wc := *c
+	wc.config = &config
+	return &wc, oggOpusChunks(ogg, time.Duration(c.config.SegDuration)*time.Millisecond), nil
+}
 
-	var segmentSize int
+// chunkSource 按音频格式选择分包方式，mp3按帧边界分包，pcm按字节数分包；ogg由withOggOpus处理
+func (c *AsrWsClient) chunkSource(r io.Reader) (chunkSource, error) {
 	switch c.config.Format {
 	case "mp3":
-		segmentSize = c.config.Mp3SegSize
-	case "wav":
-		// Simplified - in real implementation you'd parse WAV header
-		sizePerSec := c.config.Channel * (c.config.Bits / 8) * c.config.Rate
-		segmentSize = int(sizePerSec * c.config.SegDuration / 1000)
+		segDuration := time.Duration(c.config.SegDuration) * time.Millisecond
+		if segDuration <= 0 && c.config.Mp3SegSize <= 0 {
+			return nil, errors.New("invalid segment size, check SegDuration/Mp3SegSize")
+		}
+		return mp3Chunks(r, segDuration, c.config.Mp3SegSize), nil
 	case "pcm":
-		segmentSize = int(c.config.Rate * 2 * c.config.Channel * c.config.SegDuration / 500)
-	default:
-		return nil, fmt.Errorf("unsupported format: %s", c.config.Format)
+		// 16位PCM每秒的字节数 × 分包毫秒数 / 1000
+		bytesPerSecond := c.config.Rate * 2 * c.config.Channel
+		segmentSize := bytesPerSecond * c.config.SegDuration / 1000
+		if segmentSize <= 0 {
+			return nil, fmt.Errorf("invalid segment size %d, check SegDuration", segmentSize)
+		}
+		return fixedChunks(r, segmentSize, bytesPerSecond), nil
 	}
-
-	return c.processData(data, segmentSize)
+	return nil, fmt.Errorf("unsupported format: %s", c.config.Format)
 }
 
-func (c *AsrWsClient) processData(data []byte, segmentSize int) (*Response, error) {
+// processData 建立连接并分包发送音频，onResponse不为空时每收到一个响应都会回调
+func (c *AsrWsClient) processData(ctx context.Context, chunks <-chan audioChunk, onResponse func(*Response)) (*Response, error) {
 	reqID := uuid.New().String()
 	seq := 1
 
@@ -290,11 +543,21 @@ func (c *AsrWsClient) processData(data []byte, segmentSize int) (*Response, erro
 	headers["X-Api-Request-Id"] = []string{reqID}
 
 	dialer := websocket.DefaultDialer
-	conn, _, err := dialer.Dial(c.config.WsURL, headers)
+	conn, httpResp, err := dialer.DialContext(ctx, c.config.WsURL, headers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to WebSocket: %v", err)
+		err = fmt.Errorf("failed to connect to WebSocket: %v", err)
+		if httpResp != nil {
+			// 握手被拒绝，如鉴权失败
+			return nil, &StatusError{Code: httpResp.StatusCode, Err: err}
+		}
+		return nil, err
 	}
 	defer conn.Close()
+	// 任一方向出错时取消ctx并关闭连接，另一方向随之退出
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
 
 	if err := conn.WriteMessage(websocket.BinaryMessage, fullClientRequest); err != nil {
 		return nil, fmt.Errorf("failed to send initial request: %v", err)
@@ -307,56 +570,144 @@ func (c *AsrWsClient) processData(data []byte, segmentSize int) (*Response, erro
 
 	result := parseResponse(res)
 	log.Printf("Initial response: %+v", result)
+	if result.Code != 0 {
+		return nil, asrServerError(result)
+	}
+
+	// 发送与接收分别在两个goroutine中进行，服务端响应慢不会阻塞上传
+	type received struct {
+		resp *Response
+		err  error
+	}
+	done := make(chan received, 1)
+	go func() {
+		resp, err := receiveResponses(conn, &c.config.Options, onResponse)
+		if err != nil {
+			cancel(err)
+		}
+		done <- received{resp, err}
+	}()
+
+	if err := c.sendChunks(ctx, conn, chunks, seq); err != nil {
+		cancel(err)
+		if ctx.Err() != nil && context.Cause(ctx) != err {
+			// 接收方向先出错，发送失败只是连接被关闭的结果
+			return nil, context.Cause(ctx)
+		}
+		return nil, err
+	}
+
+	r := <-done
+	if r.err != nil {
+		if ctx.Err() != nil && context.Cause(ctx) != r.err {
+			return nil, context.Cause(ctx)
+		}
+		return nil, r.err
+	}
+	return r.resp, nil
+}
 
-	for chunkData := range c.sliceData(data, segmentSize) {
+// sendChunks 依次发送音频分包直到最后一包
+// Streaming为true时按分包的音频时长控制实时节奏(时长未知时按SegDuration)，否则受连接的写入速度限制尽快发送，适合离线文件
+func (c *AsrWsClient) sendChunks(ctx context.Context, conn *websocket.Conn, chunks <-chan audioChunk, seq int) error {
+	sessionStart := time.Now()
+	var sent time.Duration // 已发送音频的时长
+	for {
+		var chunkData audioChunk
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case data, ok := <-chunks:
+			if !ok {
+				if ctx.Err() != nil {
+					return context.Cause(ctx)
+				}
+				return errors.New("audio stream closed before the last chunk")
+			}
+			chunkData = data
+		}
+		if chunkData.Err != nil {
+			return chunkData.Err
+		}
 		seq++
+		flags := byte(POS_SEQUENCE)
 		if chunkData.Last {
 			seq = -seq
+			flags = NEG_WITH_SEQUENCE
 		}
 
-		start := time.Now()
-
 		var buf bytes.Buffer
 		gz := gzip.NewWriter(&buf)
 		if _, err := gz.Write(chunkData.Chunk); err != nil {
-			return nil, fmt.Errorf("failed to compress chunk: %v", err)
+			return fmt.Errorf("failed to compress chunk: %v", err)
 		}
 		if err := gz.Close(); err != nil {
-			return nil, fmt.Errorf("failed to close gzip writer: %v", err)
+			return fmt.Errorf("failed to close gzip writer: %v", err)
 		}
 		compressedChunk := buf.Bytes()
 
-		var audioOnlyRequest []byte
-		if chunkData.Last {
-			audioOnlyRequest = generateHeader(AUDIO_ONLY_REQUEST, NEG_WITH_SEQUENCE, JSON, GZIP_COMPRESSION, 0x00)
-		} else {
-			audioOnlyRequest = generateHeader(AUDIO_ONLY_REQUEST, POS_SEQUENCE, JSON, GZIP_COMPRESSION, 0x00)
-		}
-
+		audioOnlyRequest := generateHeader(AUDIO_ONLY_REQUEST, flags, JSON, GZIP_COMPRESSION, 0x00)
 		audioOnlyRequest = append(audioOnlyRequest, generateBeforePayload(seq)...)
-		audioOnlyRequest = append(audioOnlyRequest, make([]byte, 4)...)
-		binary.BigEndian.PutUint32(audioOnlyRequest[len(audioOnlyRequest)-4:], uint32(len(compressedChunk)))
+		audioOnlyRequest = binary.BigEndian.AppendUint32(audioOnlyRequest, uint32(len(compressedChunk)))
 		audioOnlyRequest = append(audioOnlyRequest, compressedChunk...)
 
 		if err := conn.WriteMessage(websocket.BinaryMessage, audioOnlyRequest); err != nil {
-			return nil, fmt.Errorf("failed to send audio chunk: %v", err)
+			return fmt.Errorf("failed to send audio chunk: %v", err)
+		}
+		if chunkData.Last {
+			return nil
+		}
+
+		// 按会话起点计算节奏，实时来源本身读取较慢时不会额外等待
+		if chunkData.Duration > 0 {
+			sent += chunkData.Duration
+		} else {
+			sent += time.Duration(c.config.SegDuration) * time.Millisecond
+		}
+		if c.config.Streaming {
+			sleepTime := sent - time.Since(sessionStart)
+			if sleepTime > 0 {
+				timer := time.NewTimer(sleepTime)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return context.Cause(ctx)
+				}
+			}
 		}
+	}
+}
 
+// receiveResponses 持续读取服务端响应直到最后一包，onResponse在接收goroutine中回调
+func receiveResponses(conn *websocket.Conn, opts *AsrRequestOptions, onResponse func(*Response)) (*Response, error) {
+	for {
 		_, res, err := conn.ReadMessage()
 		if err != nil {
 			return nil, fmt.Errorf("failed to read response: %v", err)
 		}
 
-		result = parseResponse(res)
-		log.Printf("Response for seq %d: %+v", seq, result)
-
-		if c.config.Streaming {
-			sleepTime := time.Duration(c.config.SegDuration)*time.Millisecond - time.Since(start)
-			if sleepTime > 0 {
-				time.Sleep(sleepTime)
-			}
+		result := parseResponse(res)
+		log.Printf("Response for seq %d: %+v", int32(result.PayloadSequence), result)
+		if result.Code != 0 {
+			return nil, asrServerError(result)
+		}
+		opts.trimResult(result.Result)
+		if onResponse != nil {
+			onResponse(result)
+		}
+		if result.IsLastPackage {
+			return result, nil
 		}
 	}
+}
 
-	return result, nil
+// asrServerError 将服务端错误响应转换为error
+// 45开头的错误码表示请求有误，归为400，其余归为服务端错误
+func asrServerError(resp *Response) error {
+	status := http.StatusInternalServerError
+	if resp.Code/1000000 == 45 {
+		status = http.StatusBadRequest
+	}
+	return &StatusError{Code: status, Err: fmt.Errorf("asr server error %d: %v", resp.Code, resp.PayloadMsg)}
 }
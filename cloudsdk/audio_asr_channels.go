@@ -0,0 +1,220 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ChannelOptions 分声道识别的参数
+type ChannelOptions struct {
+	Speakers []string // 各声道的说话人标签，如{"agent", "customer"}，缺省为"ch0"、"ch1"…
+
+	// Segmented 为true时每个声道先按静音切分再识别，适合超过单次会话时长的录音
+	Segmented bool
+	Segment   SegmentOptions
+}
+
+// speaker 返回声道的说话人标签
+func (o *ChannelOptions) speaker(ch int) string {
+	if ch < len(o.Speakers) && o.Speakers[ch] != "" {
+		return o.Speakers[ch]
+	}
+	return fmt.Sprintf("ch%d", ch)
+}
+
+// ChannelUtterance 带声道和说话人标签的分句
+type ChannelUtterance struct {
+	AsrUtterance
+	Channel int
+	Speaker string
+}
+
+// ChannelTranscript 分声道识别结果
+type ChannelTranscript struct {
+	Channels   []*AsrResult       // 各声道的识别结果，下标为声道号
+	Utterances []ChannelUtterance // 所有声道的分句按开始时间合并
+}
+
+// Dialogue 按时间顺序输出对话文本，每个分句一行，如"[00:00:01.200] agent: 您好"
+func (t *ChannelTranscript) Dialogue() string {
+	var b strings.Builder
+	for _, u := range t.Utterances {
+		fmt.Fprintf(&b, "[%s] %s: %s\n", formatTimestamp(u.Start(), "."), u.Speaker, strings.TrimSpace(u.Text))
+	}
+	return b.String()
+}
+
+// channel 取出第ch个声道为单声道音频
+func (a *PCMAudio) channel(ch int) *PCMAudio {
+	frames := a.Frames()
+	out := &PCMAudio{SampleRate: a.SampleRate, Channels: 1, Samples: make([]int16, frames)}
+	for i := 0; i < frames; i++ {
+		out.Samples[i] = a.Samples[i*a.Channels+ch]
+	}
+	return out
+}
+
+// RecognizeChannels 将多声道录音拆分为单声道，每个声道使用独立的会话并发识别，
+// 再把分句按时间合并并标注说话人，适用于坐席和客户分别录在左右声道的通话录音
+func (c *AsrWsClient) RecognizeChannels(ctx context.Context, a *PCMAudio, opts ChannelOptions) (*ChannelTranscript, error) {
+	if a == nil || a.SampleRate <= 0 || a.Channels <= 0 {
+		return nil, errors.New("invalid audio")
+	}
+	if !opts.Segmented {
+		return c.withPCMInput(a.SampleRate, a.Channels).RecognizeChannelsReader(ctx, bytes.NewReader(a.Bytes()), opts)
+	}
+
+	client := c.channelClient(PCMFormat{SampleRate: a.SampleRate, Channels: 1, Bits: 16})
+	results := make([]*AsrResult, a.Channels)
+	err := runBatchN(ctx, a.Channels, a.Channels, func(ctx context.Context, ch int) error {
+		result, err := client.RecognizeLong(ctx, a.channel(ch), LongAudioOptions{SegmentOptions: opts.Segment})
+		if err != nil {
+			return fmt.Errorf("channel %d: %v", ch, err)
+		}
+		results[ch] = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeChannelResults(results, &opts), nil
+}
+
+// RecognizeChannelsReader 与RecognizeChannels相同，但从r中边读边按声道拆分，不需要先把整段录音读入内存
+// r的格式由配置的Format决定，支持wav、pcm和G.711，声道数取自WAV头或配置的Channel；
+// 各声道按采样率和位深转换后分别发送，opts.Segmented对流式输入不可用
+func (c *AsrWsClient) RecognizeChannelsReader(ctx context.Context, r io.Reader, opts ChannelOptions) (*ChannelTranscript, error) {
+	if opts.Segmented {
+		return nil, errors.New("segmented channel recognition requires decoded audio, use RecognizeChannels")
+	}
+	if law, ok := g711Law(c.config.Format); ok {
+		c, r = c.withG711(r, law, G711SampleRate, c.config.Channel)
+	}
+	if c.config.Format == "wav" {
+		wc, data, err := c.withWAVHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		c, r = wc, data
+	}
+	if c.config.Format != "pcm" {
+		return nil, fmt.Errorf("unsupported format for channel recognition: %s", c.config.Format)
+	}
+	in := c.config.pcmFormat()
+	if err := in.validate(); err != nil {
+		return nil, err
+	}
+
+	client := c.channelClient(PCMFormat{SampleRate: in.SampleRate, Channels: 1, Bits: in.Bits, Float: in.Float})
+	channels := splitChannels(r, in)
+	results := make([]*AsrResult, in.Channels)
+	err := runBatchN(ctx, in.Channels, in.Channels, func(ctx context.Context, ch int) error {
+		// 提前结束的声道关闭自己的管道，使拆分goroutine和其余声道随之结束
+		defer channels[ch].Close()
+		resp, err := client.RecognizeReader(ctx, channels[ch])
+		if err != nil {
+			return fmt.Errorf("channel %d: %v", ch, err)
+		}
+		results[ch] = resp.Result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeChannelResults(results, &opts), nil
+}
+
+// channelClient 返回识别单个声道的客户端副本，总是请求分句以便按时间合并各声道
+func (c *AsrWsClient) channelClient(in PCMFormat) *AsrWsClient {
+	config := *c.config
+	config.Format = "pcm"
+	config.Rate = in.SampleRate
+	config.Channel = in.Channels
+	config.Bits = in.Bits
+	config.Float = in.Float
+	config.Options.ShowUtterances = true
+	client := *c
+	client.config = &config
+	return &client
+}
+
+// splitChannels 在后台goroutine中把交织的PCM流按声道拆分，每个声道对应一个管道
+// 任一声道的读取方关闭管道后，拆分随之停止，其余声道读到相同的错误
+func splitChannels(r io.Reader, in PCMFormat) []*io.PipeReader {
+	readers := make([]*io.PipeReader, in.Channels)
+	writers := make([]*io.PipeWriter, in.Channels)
+	for ch := range readers {
+		readers[ch], writers[ch] = io.Pipe()
+	}
+
+	go func() {
+		frameSize := in.frameSize()
+		sampleSize := in.Bits / 8
+		buf := make([]byte, 1024*frameSize)
+		out := make([][]byte, in.Channels)
+		var pending []byte // 未凑满一帧的剩余字节
+		var err error
+		for err == nil {
+			var n int
+			n, err = r.Read(buf)
+			data := append(pending, buf[:n]...)
+			usable := len(data) - len(data)%frameSize
+			for ch := range out {
+				out[ch] = out[ch][:0]
+			}
+			for i := 0; i < usable; i += frameSize {
+				for ch := range out {
+					off := i + ch*sampleSize
+					out[ch] = append(out[ch], data[off:off+sampleSize]...)
+				}
+			}
+			pending = append([]byte(nil), data[usable:]...)
+			for ch, w := range writers {
+				if len(out[ch]) == 0 {
+					continue
+				}
+				if _, werr := w.Write(out[ch]); werr != nil {
+					err = werr
+					break
+				}
+			}
+		}
+		if err == io.EOF {
+			err = nil
+		}
+		for _, w := range writers {
+			w.CloseWithError(err)
+		}
+	}()
+	return readers
+}
+
+// mergeChannelResults 合并各声道的分句，开始时间相同时按声道号排序
+func mergeChannelResults(results []*AsrResult, opts *ChannelOptions) *ChannelTranscript {
+	t := &ChannelTranscript{Channels: results}
+	for ch, r := range results {
+		utterances := r.Utterances()
+		if len(utterances) == 0 && strings.TrimSpace(r.Text()) != "" {
+			// 服务端未返回分句时整个声道作为一个分句
+			utterances = []AsrUtterance{{Text: r.Text(), EndTime: r.AudioInfo.Duration, Definite: true}}
+		}
+		for _, u := range utterances {
+			if strings.TrimSpace(u.Text) == "" {
+				continue
+			}
+			t.Utterances = append(t.Utterances, ChannelUtterance{AsrUtterance: u, Channel: ch, Speaker: opts.speaker(ch)})
+		}
+	}
+	sort.SliceStable(t.Utterances, func(i, j int) bool {
+		if t.Utterances[i].StartTime != t.Utterances[j].StartTime {
+			return t.Utterances[i].StartTime < t.Utterances[j].StartTime
+		}
+		return t.Utterances[i].Channel < t.Utterances[j].Channel
+	})
+	return t
+}
@@ -0,0 +1,95 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsrWsClient_RecognizeChannels(t *testing.T) {
+	server := newFakeAsrServer(t)
+	// 按声道内容返回不同的分句：左声道为1000，右声道为2000
+	server.result = func(audio []byte, last bool) interface{} {
+		if int16(binary.LittleEndian.Uint16(audio)) == 1000 {
+			return utteranceResult("您好请问有什么可以帮您好的稍等",
+				utterance("您好请问有什么可以帮您", 0, 500, true),
+				utterance("好的稍等", 1200, 1500, true))
+		}
+		return utteranceResult("我想查一下账单", utterance("我想查一下账单", 600, 1000, true))
+	}
+	client := newTestAsrClient(server, "pcm")
+
+	a := &PCMAudio{SampleRate: 16000, Channels: 2, Samples: make([]int16, 2*16000)}
+	for i := 0; i < len(a.Samples); i += 2 {
+		a.Samples[i], a.Samples[i+1] = 1000, 2000
+	}
+	transcript, err := client.RecognizeChannels(context.Background(), a, ChannelOptions{Speakers: []string{"agent", "customer"}})
+	require.NoError(t, err)
+
+	require.Len(t, transcript.Channels, 2)
+	assert.Equal(t, "我想查一下账单", transcript.Channels[1].Text())
+	var speakers, texts []string
+	for _, u := range transcript.Utterances {
+		speakers = append(speakers, u.Speaker)
+		texts = append(texts, u.Text)
+	}
+	assert.Equal(t, []string{"agent", "customer", "agent"}, speakers)
+	assert.Equal(t, []string{"您好请问有什么可以帮您", "我想查一下账单", "好的稍等"}, texts)
+	assert.Equal(t, "[00:00:00.000] agent: 您好请问有什么可以帮您\n"+
+		"[00:00:00.600] customer: 我想查一下账单\n"+
+		"[00:00:01.200] agent: 好的稍等\n", transcript.Dialogue())
+
+	// 每个声道单独发送，两个会话收到的都是单声道音频
+	audio := server.request["audio"].(map[string]interface{})
+	assert.Equal(t, float64(1), audio["channel"])
+	assert.Len(t, server.Audio(), 2*16000*2)
+}
+
+func TestMergeChannelResults(t *testing.T) {
+	left := &AsrResult{}
+	left.Result.Text = "只有文本"
+	left.AudioInfo.Duration = 800
+	right := &AsrResult{}
+	right.Result.Utterances = []AsrUtterance{{Text: " ", StartTime: 0}, {Text: "同时开始", StartTime: 0, EndTime: 300}}
+
+	transcript := mergeChannelResults([]*AsrResult{left, right, nil}, &ChannelOptions{})
+	require.Len(t, transcript.Utterances, 2)
+	assert.Equal(t, "ch0", transcript.Utterances[0].Speaker)
+	assert.Equal(t, 800, transcript.Utterances[0].EndTime)
+	assert.Equal(t, "ch1", transcript.Utterances[1].Speaker)
+	assert.Equal(t, "同时开始", transcript.Utterances[1].Text)
+}
+
+func TestAsrWsClient_RecognizeChannelsReader(t *testing.T) {
+	server := newFakeAsrServer(t)
+	server.result = func(audio []byte, last bool) interface{} {
+		if int16(binary.LittleEndian.Uint16(audio)) == 1000 {
+			return utteranceResult("左", utterance("左", 0, 300, true))
+		}
+		return utteranceResult("右", utterance("右", 100, 400, true))
+	}
+
+	a := &PCMAudio{SampleRate: 16000, Channels: 2, Samples: make([]int16, 2*16000)}
+	for i := 0; i < len(a.Samples); i += 2 {
+		a.Samples[i], a.Samples[i+1] = 1000, 2000
+	}
+	// WAV头中的声道数决定拆分方式，分片读取不影响结果
+	client := newTestAsrClient(server, "wav")
+	transcript, err := client.RecognizeChannelsReader(context.Background(), iotest.HalfReader(bytes.NewReader(a.WAV())), ChannelOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "[00:00:00.000] ch0: 左\n[00:00:00.100] ch1: 右\n", transcript.Dialogue())
+	assert.Len(t, server.Audio(), 2*16000*2)
+
+	// 一个声道失败时返回错误，另一个声道不会阻塞
+	server.fail = func(audio []byte) bool { return int16(binary.LittleEndian.Uint16(audio)) == 2000 }
+	_, err = client.RecognizeChannelsReader(context.Background(), bytes.NewReader(a.WAV()), ChannelOptions{})
+	assert.ErrorContains(t, err, "channel 1")
+
+	_, err = newTestAsrClient(server, "mp3").RecognizeChannelsReader(context.Background(), bytes.NewReader(nil), ChannelOptions{})
+	assert.ErrorContains(t, err, "unsupported format")
+}
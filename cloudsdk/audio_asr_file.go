@@ -0,0 +1,252 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	fileAsrBaseURL    = "https://openspeech.bytedance.com/api/v3/auc/bigmodel"
+	fileAsrResourceID = "volc.bigasr.auc"
+)
+
+// 录音文件识别接口在X-Api-Status-Code响应头中返回的状态码
+const (
+	fileAsrSuccess    = 20000000
+	fileAsrProcessing = 20000001
+	fileAsrQueued     = 20000002
+	fileAsrSilent     = 20000003 // 音频中没有语音，结果为空
+)
+
+// FileAsrClient 录音文件识别(提交/查询)客户端，适用于已录制好的长音频
+type FileAsrClient struct {
+	appKey     string
+	accessKey  string
+	uid        string
+	baseURL    string
+	httpClient *http.Client
+
+	pollInterval    time.Duration // Wait首次轮询间隔，默认1s
+	maxPollInterval time.Duration // Wait最大轮询间隔，默认15s
+}
+
+// FileAsrRequest 一次录音文件识别请求，URL和Audio二选一
+type FileAsrRequest struct {
+	URL     string // 可公开访问的音频地址
+	Audio   []byte // 直接上传的音频数据
+	Format  string // wav/mp3/ogg等，为空时由服务端识别
+	Codec   string
+	Rate    int
+	Bits    int
+	Channel int
+	Options AsrRequestOptions
+}
+
+// FileAsrError 录音文件识别接口返回的错误
+type FileAsrError struct {
+	TaskID  string
+	Code    int
+	Message string
+}
+
+func (e *FileAsrError) Error() string {
+	return fmt.Sprintf("file asr task %s failed with code %d: %s", e.TaskID, e.Code, e.Message)
+}
+
+// retryable 服务端内部错误可以重试
+func (e *FileAsrError) retryable() bool {
+	return e.Code/10000000 == 5
+}
+
+// NewFileAsrClient 创建录音文件识别客户端，appKey/accessKey与流式识别的AppKey/AccessKey相同
+func NewFileAsrClient(appKey, accessKey string) *FileAsrClient {
+	return &FileAsrClient{
+		appKey:     appKey,
+		accessKey:  accessKey,
+		baseURL:    fileAsrBaseURL,
+		httpClient: &http.Client{Timeout: time.Minute},
+
+		pollInterval:    time.Second,
+		maxPollInterval: 15 * time.Second,
+	}
+}
+
+// WithHTTPClient 替换默认的HTTP客户端
+func (c *FileAsrClient) WithHTTPClient(hc *http.Client) *FileAsrClient {
+	c.httpClient = hc
+	return c
+}
+
+// WithBaseURL 替换接口地址，用于私有化部署或测试
+func (c *FileAsrClient) WithBaseURL(baseURL string) *FileAsrClient {
+	c.baseURL = strings.TrimRight(baseURL, "/")
+	return c
+}
+
+// WithUID 设置请求中的用户标识
+func (c *FileAsrClient) WithUID(uid string) *FileAsrClient {
+	c.uid = uid
+	return c
+}
+
+// WithPollInterval 设置Wait的轮询间隔，每次轮询后间隔翻倍直到max
+func (c *FileAsrClient) WithPollInterval(initial, max time.Duration) *FileAsrClient {
+	c.pollInterval = initial
+	c.maxPollInterval = max
+	return c
+}
+
+// fileAsrSubmitRequest 提交接口的请求体
+type fileAsrSubmitRequest struct {
+	User struct {
+		UID string `json:"uid"`
+	} `json:"user"`
+	Audio struct {
+		URL      string `json:"url,omitempty"`
+		Data     string `json:"data,omitempty"`
+		Format   string `json:"format,omitempty"`
+		Codec    string `json:"codec,omitempty"`
+		Rate     int    `json:"rate,omitempty"`
+		Bits     int    `json:"bits,omitempty"`
+		Channel  int    `json:"channel,omitempty"`
+		Language string `json:"language,omitempty"`
+	} `json:"audio"`
+	Request asrRequestParams `json:"request"`
+}
+
+// post 调用接口，返回响应头中的状态码和响应体
+func (c *FileAsrClient) post(ctx context.Context, path, taskID string, payload interface{}) (int, []byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Api-App-Key", c.appKey)
+	req.Header.Set("X-Api-Access-Key", c.accessKey)
+	req.Header.Set("X-Api-Resource-Id", fileAsrResourceID)
+	req.Header.Set("X-Api-Request-Id", taskID)
+	req.Header.Set("X-Api-Sequence", "-1")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	code, err := strconv.Atoi(resp.Header.Get("X-Api-Status-Code"))
+	if err != nil {
+		return 0, data, fmt.Errorf("unexpected http status %d: %s", resp.StatusCode, string(data))
+	}
+	if code != fileAsrSuccess && code != fileAsrProcessing && code != fileAsrQueued && code != fileAsrSilent {
+		return code, data, &FileAsrError{TaskID: taskID, Code: code, Message: resp.Header.Get("X-Api-Message")}
+	}
+	return code, data, nil
+}
+
+// Submit 提交识别任务并返回任务ID
+func (c *FileAsrClient) Submit(ctx context.Context, req *FileAsrRequest) (string, error) {
+	if (req.URL == "") == (len(req.Audio) == 0) {
+		return "", errors.New("exactly one of URL and Audio is required")
+	}
+	if err := req.Options.validate(); err != nil {
+		return "", err
+	}
+
+	payload := &fileAsrSubmitRequest{Request: req.Options.params()}
+	payload.User.UID = c.uid
+	payload.Audio.URL = req.URL
+	if len(req.Audio) > 0 {
+		payload.Audio.Data = base64.StdEncoding.EncodeToString(req.Audio)
+	}
+	payload.Audio.Format = req.Format
+	payload.Audio.Codec = req.Codec
+	payload.Audio.Rate = req.Rate
+	payload.Audio.Bits = req.Bits
+	payload.Audio.Channel = req.Channel
+	payload.Audio.Language = req.Options.Language
+
+	taskID := uuid.New().String()
+	if _, _, err := c.post(ctx, "/submit", taskID, payload); err != nil {
+		return "", fmt.Errorf("submit file asr task failed: %w", err)
+	}
+	return taskID, nil
+}
+
+// Query 查询任务，done为false表示仍在排队或处理中
+func (c *FileAsrClient) Query(ctx context.Context, taskID string) (result *AsrResult, done bool, err error) {
+	code, body, err := c.post(ctx, "/query", taskID, struct{}{})
+	if err != nil {
+		return nil, false, err
+	}
+	switch code {
+	case fileAsrProcessing, fileAsrQueued:
+		return nil, false, nil
+	case fileAsrSilent:
+		return &AsrResult{Raw: body}, true, nil
+	}
+	result, err = parseAsrResult(body)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, true, nil
+}
+
+// Wait 轮询任务直到完成，轮询间隔逐次翻倍
+// 任务失败时返回*FileAsrError；网络错误和服务端内部错误会继续重试，直到ctx结束
+func (c *FileAsrClient) Wait(ctx context.Context, taskID string) (*AsrResult, error) {
+	var result *AsrResult
+	err := pollWithBackoff(ctx, c.pollInterval, c.maxPollInterval, func() (bool, error) {
+		r, done, err := c.Query(ctx, taskID)
+		var apiErr *FileAsrError
+		switch {
+		case errors.As(err, &apiErr) && !apiErr.retryable():
+			return false, err
+		case err != nil:
+			log.Printf("query file asr task %s failed, retrying: %v", taskID, err)
+			return false, nil
+		}
+		result = r
+		return done, nil
+	})
+	if err != nil && err == ctx.Err() {
+		return nil, fmt.Errorf("wait for file asr task %s: %w", taskID, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Transcribe 提交任务并等待识别结果
+func (c *FileAsrClient) Transcribe(ctx context.Context, req *FileAsrRequest) (*AsrResult, error) {
+	taskID, err := c.Submit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.Wait(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	req.Options.trimResult(result)
+	return result, nil
+}
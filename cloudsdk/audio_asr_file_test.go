@@ -0,0 +1,165 @@
+package cloudsdk
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFileAsrServer 模拟录音文件识别接口，任务在查询pending次之后完成
+type fakeFileAsrServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	submits []map[string]interface{}
+	queries map[string]int
+
+	pending  int
+	failCode int // 不为0时查询返回该错误码
+	flaky    int // 前flaky次查询返回HTTP 502
+}
+
+func newFakeFileAsrServer(t *testing.T) *fakeFileAsrServer {
+	s := &fakeFileAsrServer{queries: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "app", r.Header.Get("X-Api-App-Key"))
+		assert.Equal(t, "access", r.Header.Get("X-Api-Access-Key"))
+		assert.Equal(t, fileAsrResourceID, r.Header.Get("X-Api-Resource-Id"))
+		taskID := r.Header.Get("X-Api-Request-Id")
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); !assert.NoError(t, err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		status := func(code int, msg string) {
+			w.Header().Set("X-Api-Status-Code", strconv.Itoa(code))
+			w.Header().Set("X-Api-Message", msg)
+		}
+		switch r.URL.Path {
+		case "/submit":
+			s.submits = append(s.submits, body)
+			s.queries[taskID] = 0
+			status(fileAsrSuccess, "OK")
+			w.Write([]byte("{}"))
+		case "/query":
+			n, ok := s.queries[taskID]
+			if !ok {
+				status(45000001, "task not found")
+				return
+			}
+			s.queries[taskID] = n + 1
+			switch {
+			case s.flaky > 0:
+				s.flaky--
+				w.WriteHeader(http.StatusBadGateway)
+			case s.failCode != 0:
+				status(s.failCode, "invalid audio")
+			case n < s.pending:
+				status(fileAsrProcessing, "processing")
+				w.Write([]byte("{}"))
+			default:
+				status(fileAsrSuccess, "OK")
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"audio_info": map[string]interface{}{"duration": 3000},
+					"result": utteranceResult("你好世界",
+						utterance("你好世界", 100, 2800, true)),
+				})
+			}
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestFileAsrClient(s *fakeFileAsrServer) *FileAsrClient {
+	return NewFileAsrClient("app", "access").WithBaseURL(s.URL).WithPollInterval(time.Millisecond, 5*time.Millisecond)
+}
+
+func TestFileAsrClient_TranscribeURL(t *testing.T) {
+	server := newFakeFileAsrServer(t)
+	server.pending = 3
+	server.flaky = 1
+
+	result, err := newTestFileAsrClient(server).Transcribe(context.Background(), &FileAsrRequest{
+		URL:     "https://example.com/a.mp3",
+		Format:  "mp3",
+		Options: AsrRequestOptions{EnableSpeakerInfo: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "你好世界", result.Text())
+	assert.Equal(t, 3000, result.AudioInfo.Duration)
+	require.Len(t, result.Utterances(), 1)
+	assert.Equal(t, 100*time.Millisecond, result.Utterances()[0].Start())
+
+	require.Len(t, server.submits, 1)
+	audio := server.submits[0]["audio"].(map[string]interface{})
+	assert.Equal(t, "https://example.com/a.mp3", audio["url"])
+	assert.Equal(t, "mp3", audio["format"])
+	request := server.submits[0]["request"].(map[string]interface{})
+	assert.Equal(t, true, request["enable_speaker_info"])
+}
+
+func TestFileAsrClient_Upload(t *testing.T) {
+	server := newFakeFileAsrServer(t)
+	client := newTestFileAsrClient(server)
+
+	taskID, err := client.Submit(context.Background(), &FileAsrRequest{Audio: []byte("RIFFdata"), Format: "wav"})
+	require.NoError(t, err)
+	audio := server.submits[0]["audio"].(map[string]interface{})
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("RIFFdata")), audio["data"])
+
+	result, done, err := client.Query(context.Background(), taskID)
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, "你好世界", result.Text())
+
+	_, err = client.Submit(context.Background(), &FileAsrRequest{})
+	assert.Error(t, err)
+}
+
+func TestFileAsrClient_Failed(t *testing.T) {
+	server := newFakeFileAsrServer(t)
+	server.failCode = 45000151
+
+	_, err := newTestFileAsrClient(server).Transcribe(context.Background(), &FileAsrRequest{URL: "https://example.com/a.mp3"})
+	var apiErr *FileAsrError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 45000151, apiErr.Code)
+	assert.Equal(t, "invalid audio", apiErr.Message)
+}
+
+func TestPollWithBackoff(t *testing.T) {
+	var calls []time.Time
+	err := pollWithBackoff(context.Background(), 10*time.Millisecond, 25*time.Millisecond, func() (bool, error) {
+		calls = append(calls, time.Now())
+		return len(calls) == 4, nil
+	})
+	require.NoError(t, err)
+	require.Len(t, calls, 4)
+	// 间隔依次为10ms、20ms、25ms(封顶)
+	for i, want := range []time.Duration{10, 20, 25} {
+		assert.GreaterOrEqual(t, calls[i+1].Sub(calls[i]), want*time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	err = pollWithBackoff(ctx, 10*time.Millisecond, time.Second, func() (bool, error) { return false, nil })
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	stop := errors.New("stop")
+	assert.Equal(t, stop, pollWithBackoff(context.Background(), time.Hour, time.Hour, func() (bool, error) { return false, stop }))
+}
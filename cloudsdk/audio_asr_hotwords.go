@@ -0,0 +1,135 @@
+package cloudsdk
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// 直传热词和上下文的限制，超出时服务端会拒绝请求或截断
+const (
+	MaxHotWords          = 100 // 直传热词个数上限
+	MaxHotWordLength     = 10  // 单个热词的字数上限
+	MaxContextItems      = 20  // 上下文条数上限
+	MaxContextTextLength = 800 // 上下文总字数上限
+)
+
+// AsrCorpus 请求中的热词与上下文配置
+type AsrCorpus struct {
+	BoostingTableID   string `json:"boosting_table_id,omitempty"`
+	BoostingTableName string `json:"boosting_table_name,omitempty"`
+	Context           string `json:"context,omitempty"` // JSON字符串，包含直传热词和对话上下文
+}
+
+// asrContext corpus.context中的内容
+type asrContext struct {
+	HotWords    []asrHotWord     `json:"hotwords,omitempty"`
+	ContextType string           `json:"context_type,omitempty"`
+	ContextData []asrContextText `json:"context_data,omitempty"`
+}
+
+type asrHotWord struct {
+	Word string `json:"word"`
+}
+
+type asrContextText struct {
+	Text string `json:"text"`
+}
+
+// LoadHotWords 读取热词文件，每行一个热词，忽略空行和#开头的注释行
+func LoadHotWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hot word file: %v", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hot word file: %v", err)
+	}
+	return words, nil
+}
+
+// hotWords 合并HotWords和HotWordList，去掉空白和重复项并保持顺序
+func (c *AsrConfig) hotWords() []string {
+	split := strings.FieldsFunc(c.HotWords, func(r rune) bool {
+		return r == ',' || r == '，' || r == '\n'
+	})
+
+	seen := make(map[string]bool)
+	var words []string
+	for _, w := range append(split, c.HotWordList...) {
+		w = strings.TrimSpace(w)
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	return words
+}
+
+// validateCorpus 检查热词和上下文是否超出限制
+func (c *AsrConfig) validateCorpus() error {
+	words := c.hotWords()
+	if len(words) > MaxHotWords {
+		return fmt.Errorf("too many hot words: %d, at most %d", len(words), MaxHotWords)
+	}
+	for _, w := range words {
+		if n := utf8.RuneCountInString(w); n > MaxHotWordLength {
+			return fmt.Errorf("hot word %q too long: %d characters, at most %d", w, n, MaxHotWordLength)
+		}
+	}
+
+	if len(c.Context) > MaxContextItems {
+		return fmt.Errorf("too many context items: %d, at most %d", len(c.Context), MaxContextItems)
+	}
+	total := 0
+	for _, text := range c.Context {
+		total += utf8.RuneCountInString(text)
+	}
+	if total > MaxContextTextLength {
+		return fmt.Errorf("context too long: %d characters, at most %d", total, MaxContextTextLength)
+	}
+	return nil
+}
+
+// corpus 构造请求中的corpus字段，未配置热词和上下文时返回nil
+func (c *AsrWsClient) corpus() *AsrCorpus {
+	corpus := &AsrCorpus{
+		BoostingTableID:   c.config.BoostingTableID,
+		BoostingTableName: c.config.BoostingTableName,
+	}
+
+	var ctx asrContext
+	for _, w := range c.config.hotWords() {
+		ctx.HotWords = append(ctx.HotWords, asrHotWord{Word: w})
+	}
+	for _, text := range c.config.Context {
+		ctx.ContextData = append(ctx.ContextData, asrContextText{Text: text})
+	}
+	if len(ctx.ContextData) > 0 {
+		ctx.ContextType = "dialog_ctx"
+	}
+	if len(ctx.HotWords) > 0 || len(ctx.ContextData) > 0 {
+		data, _ := json.Marshal(ctx)
+		corpus.Context = string(data)
+	}
+
+	if *corpus == (AsrCorpus{}) {
+		return nil
+	}
+	return corpus
+}
@@ -0,0 +1,68 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadHotWords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hotwords.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# 药材\n益母草\n\n  当归 \n"), 0644))
+
+	words, err := LoadHotWords(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"益母草", "当归"}, words)
+
+	_, err = LoadHotWords(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestAsrConfig_ValidateCorpus(t *testing.T) {
+	config := &AsrConfig{HotWords: "益母草, 当归，益母草", HotWordList: []string{"当归", "黄芪"}}
+	assert.Equal(t, []string{"益母草", "当归", "黄芪"}, config.hotWords())
+	assert.NoError(t, config.validateCorpus())
+
+	config.HotWordList = []string{"这是一个超过十个字的很长热词"}
+	assert.ErrorContains(t, config.validateCorpus(), "too long")
+
+	config.HotWordList = make([]string, MaxHotWords+1)
+	for i := range config.HotWordList {
+		config.HotWordList[i] = fmt.Sprintf("词%d", i)
+	}
+	config.HotWords = ""
+	assert.ErrorContains(t, config.validateCorpus(), "too many hot words")
+
+	config = &AsrConfig{Context: []string{strings.Repeat("字", MaxContextTextLength+1)}}
+	assert.ErrorContains(t, config.validateCorpus(), "context too long")
+}
+
+func TestAsrWsClient_Corpus(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "pcm")
+	client.config.HotWords = "益母草"
+	client.config.BoostingTableID = "table-1"
+	client.config.Context = []string{"今天讲一个中草药的故事"}
+
+	_, err := client.RecognizeReader(context.Background(), bytes.NewReader(make([]byte, 100)))
+	require.NoError(t, err)
+
+	corpus := server.request["request"].(map[string]interface{})["corpus"].(map[string]interface{})
+	assert.Equal(t, "table-1", corpus["boosting_table_id"])
+	var ctx asrContext
+	require.NoError(t, json.Unmarshal([]byte(corpus["context"].(string)), &ctx))
+	assert.Equal(t, []asrHotWord{{Word: "益母草"}}, ctx.HotWords)
+	assert.Equal(t, "dialog_ctx", ctx.ContextType)
+	assert.Equal(t, []asrContextText{{Text: "今天讲一个中草药的故事"}}, ctx.ContextData)
+
+	// 未配置时不发送corpus
+	assert.Nil(t, newTestAsrClient(server, "pcm").corpus())
+}
@@ -0,0 +1,97 @@
+package cloudsdk
+
+import "fmt"
+
+// 识别结果的返回方式
+const (
+	AsrResultFull   = "full"   // 每个响应返回从开始到当前的全部结果
+	AsrResultSingle = "single" // 每个响应只返回新增的结果
+)
+
+// AsrRequestOptions bigmodel识别请求参数，零值即默认行为
+type AsrRequestOptions struct {
+	ModelName string // 模型名称，默认 "bigmodel"
+	Language  string // 音频语种，如 "zh-CN"、"en-US"，为空时由服务端判断
+
+	DisablePunc       bool // 不添加标点
+	DisableITN        bool // 关闭文本规范化(ITN)，数字、日期等保持汉字形式
+	EnableDDC         bool // 语义顺滑(DDC)，去除口语中的语气词和重复
+	EnableSpeakerInfo bool // 返回说话人信息，在分句的additions中
+	ShowUtterances    bool // 返回分句及其起止时间，默认只返回整段文本
+	ShowWords         bool // 分句中保留字级别时间戳，开启时隐含ShowUtterances
+
+	ResultType         string // AsrResultFull(默认)或AsrResultSingle
+	VADSegmentDuration int    // 服务端语音切句的最大时长，毫秒，0为服务端默认
+	EndWindowSize      int    // 静音超过该时长判定一句结束，毫秒，0为服务端默认
+	ForceToSpeechTime  int    // 音频超过该时长后才开始判停，毫秒，0为服务端默认
+}
+
+// asrRequestParams 请求中request字段的内容
+type asrRequestParams struct {
+	ModelName          string     `json:"model_name"`
+	EnablePunc         bool       `json:"enable_punc"`
+	EnableITN          *bool      `json:"enable_itn,omitempty"`
+	EnableDDC          bool       `json:"enable_ddc,omitempty"`
+	EnableSpeakerInfo  bool       `json:"enable_speaker_info,omitempty"`
+	ShowUtterances     bool       `json:"show_utterances,omitempty"`
+	ResultType         string     `json:"result_type,omitempty"`
+	VADSegmentDuration int        `json:"vad_segment_duration,omitempty"`
+	EndWindowSize      int        `json:"end_window_size,omitempty"`
+	ForceToSpeechTime  int        `json:"force_to_speech_time,omitempty"`
+	Corpus             *AsrCorpus `json:"corpus,omitempty"`
+}
+
+// validate 检查取值范围
+func (o *AsrRequestOptions) validate() error {
+	switch o.ResultType {
+	case "", AsrResultFull, AsrResultSingle:
+	default:
+		return fmt.Errorf("unsupported result type: %s", o.ResultType)
+	}
+	if o.VADSegmentDuration < 0 || o.EndWindowSize < 0 || o.ForceToSpeechTime < 0 {
+		return fmt.Errorf("invalid vad settings: segment %dms, end window %dms, force to speech %dms",
+			o.VADSegmentDuration, o.EndWindowSize, o.ForceToSpeechTime)
+	}
+	return nil
+}
+
+// params 转换为请求参数
+func (o *AsrRequestOptions) params() asrRequestParams {
+	p := asrRequestParams{
+		ModelName:          o.ModelName,
+		EnablePunc:         !o.DisablePunc,
+		EnableDDC:          o.EnableDDC,
+		EnableSpeakerInfo:  o.EnableSpeakerInfo,
+		ShowUtterances:     o.ShowUtterances || o.ShowWords,
+		ResultType:         o.ResultType,
+		VADSegmentDuration: o.VADSegmentDuration,
+		EndWindowSize:      o.EndWindowSize,
+		ForceToSpeechTime:  o.ForceToSpeechTime,
+	}
+	if p.ModelName == "" {
+		p.ModelName = "bigmodel"
+	}
+	if o.DisableITN {
+		p.EnableITN = new(bool)
+	}
+	return p
+}
+
+// trimResult 未开启ShowWords时去掉分句中的字级别时间戳，服务端的分句结果总是附带字信息
+func (o *AsrRequestOptions) trimResult(r *AsrResult) {
+	if r == nil || o.ShowWords {
+		return
+	}
+	for i := range r.Result.Utterances {
+		r.Result.Utterances[i].Words = nil
+	}
+}
+
+// WithOptions 返回使用opts作为请求参数的客户端副本，用于单次调用覆盖配置
+func (c *AsrWsClient) WithOptions(opts AsrRequestOptions) *AsrWsClient {
+	config := *c.config
+	config.Options = opts
+	wc := *c
+	wc.config = &config
+	return &wc
+}
@@ -0,0 +1,93 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requestJSON(t *testing.T, c *AsrWsClient) map[string]interface{} {
+	data, err := json.Marshal(c.constructRequest("req"))
+	require.NoError(t, err)
+	var req map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &req))
+	return req["request"]
+}
+
+func TestAsrRequestOptions_Defaults(t *testing.T) {
+	c := NewAsrWsClient(&AsrConfig{Format: "pcm"})
+	assert.Equal(t, map[string]interface{}{
+		"model_name":  "bigmodel",
+		"enable_punc": true,
+	}, requestJSON(t, c))
+}
+
+func TestAsrRequestOptions_All(t *testing.T) {
+	c := NewAsrWsClient(&AsrConfig{Format: "pcm"}).WithOptions(AsrRequestOptions{
+		Language:           "en-US",
+		DisablePunc:        true,
+		DisableITN:         true,
+		EnableDDC:          true,
+		EnableSpeakerInfo:  true,
+		ShowWords:          true,
+		ResultType:         AsrResultSingle,
+		VADSegmentDuration: 3000,
+		EndWindowSize:      800,
+		ForceToSpeechTime:  1000,
+	})
+	assert.Equal(t, map[string]interface{}{
+		"model_name":           "bigmodel",
+		"enable_punc":          false,
+		"enable_itn":           false,
+		"enable_ddc":           true,
+		"enable_speaker_info":  true,
+		"show_utterances":      true,
+		"result_type":          "single",
+		"vad_segment_duration": float64(3000),
+		"end_window_size":      float64(800),
+		"force_to_speech_time": float64(1000),
+	}, requestJSON(t, c))
+	assert.Equal(t, "en-US", c.constructRequest("req").Audio.Language)
+
+	_, err := NewAsrWsClient(&AsrConfig{Format: "pcm", Rate: 16000, SegDuration: 100}).
+		WithOptions(AsrRequestOptions{ResultType: "partial"}).
+		RecognizeReader(context.Background(), bytes.NewReader(nil))
+	assert.ErrorContains(t, err, "unsupported result type")
+}
+
+func TestAsrWsClient_TranscriptsForceFullResult(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "pcm").WithOptions(AsrRequestOptions{ResultType: AsrResultSingle})
+
+	_, err := client.RecognizeTranscripts(context.Background(), bytes.NewReader(make([]byte, 100)), func(TranscriptEvent) {})
+	require.NoError(t, err)
+	assert.Equal(t, "full", server.request["request"].(map[string]interface{})["result_type"])
+}
+
+func TestAsrRequestOptions_Words(t *testing.T) {
+	server := newFakeAsrServer(t)
+	server.result = func(audio []byte, last bool) interface{} {
+		return utteranceResult("你好", map[string]interface{}{
+			"text": "你好", "start_time": 0, "end_time": 500, "definite": true,
+			"words": []interface{}{map[string]interface{}{"text": "你", "start_time": 0, "end_time": 200}},
+		})
+	}
+
+	// 只开启分句时不保留字级别时间戳
+	client := newTestAsrClient(server, "pcm").WithOptions(AsrRequestOptions{ShowUtterances: true})
+	resp, err := client.RecognizeReader(context.Background(), bytes.NewReader(make([]byte, 3200)))
+	require.NoError(t, err)
+	require.Len(t, resp.Result.Utterances(), 1)
+	assert.Empty(t, resp.Result.Utterances()[0].Words)
+
+	client = newTestAsrClient(server, "pcm").WithOptions(AsrRequestOptions{ShowWords: true})
+	assert.Equal(t, true, requestJSON(t, client)["show_utterances"])
+	resp, err = client.RecognizeReader(context.Background(), bytes.NewReader(make([]byte, 3200)))
+	require.NoError(t, err)
+	require.Len(t, resp.Result.Utterances(), 1)
+	assert.Len(t, resp.Result.Utterances()[0].Words, 1)
+}
@@ -0,0 +1,78 @@
+package cloudsdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AsrWord 分句中的单个字或词，时间单位为毫秒
+type AsrWord struct {
+	Text          string  `json:"text"`
+	StartTime     int     `json:"start_time"`
+	EndTime       int     `json:"end_time"`
+	BlankDuration int     `json:"blank_duration"`
+	Confidence    float64 `json:"confidence"`
+}
+
+// Start 返回起始时间
+func (w AsrWord) Start() time.Duration { return time.Duration(w.StartTime) * time.Millisecond }
+
+// End 返回结束时间
+func (w AsrWord) End() time.Duration { return time.Duration(w.EndTime) * time.Millisecond }
+
+// AsrUtterance 一个分句，Definite为true表示分句已确定不会再变化，时间单位为毫秒
+type AsrUtterance struct {
+	Text      string                 `json:"text"`
+	StartTime int                    `json:"start_time"`
+	EndTime   int                    `json:"end_time"`
+	Definite  bool                   `json:"definite"`
+	Words     []AsrWord              `json:"words"`
+	Additions map[string]interface{} `json:"additions"`
+}
+
+// Start 返回起始时间
+func (u AsrUtterance) Start() time.Duration { return time.Duration(u.StartTime) * time.Millisecond }
+
+// End 返回结束时间
+func (u AsrUtterance) End() time.Duration { return time.Duration(u.EndTime) * time.Millisecond }
+
+// AsrResult bigmodel识别响应，Raw保留完整的原始JSON以便读取未建模的字段
+type AsrResult struct {
+	AudioInfo struct {
+		Duration int `json:"duration"` // 已处理的音频时长，毫秒
+	} `json:"audio_info"`
+	Result struct {
+		Text       string                 `json:"text"`
+		Utterances []AsrUtterance         `json:"utterances"`
+		Additions  map[string]interface{} `json:"additions"`
+	} `json:"result"`
+
+	Raw json.RawMessage `json:"-"`
+}
+
+// Text 返回当前整段识别文本
+func (r *AsrResult) Text() string {
+	if r == nil {
+		return ""
+	}
+	return r.Result.Text
+}
+
+// Utterances 返回分句列表
+func (r *AsrResult) Utterances() []AsrUtterance {
+	if r == nil {
+		return nil
+	}
+	return r.Result.Utterances
+}
+
+// parseAsrResult 解析服务端响应中的JSON负载
+func parseAsrResult(payload []byte) (*AsrResult, error) {
+	result := &AsrResult{}
+	if err := json.Unmarshal(payload, result); err != nil {
+		return nil, fmt.Errorf("decode asr result failed: %v", err)
+	}
+	result.Raw = append(json.RawMessage(nil), payload...)
+	return result, nil
+}
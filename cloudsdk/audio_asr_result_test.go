@@ -0,0 +1,57 @@
+package cloudsdk
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResponse_TypedResult(t *testing.T) {
+	payload := map[string]interface{}{
+		"audio_info": map[string]interface{}{"duration": 2100},
+		"result": map[string]interface{}{
+			"text":      "你好。",
+			"additions": map[string]interface{}{"log_id": "abc"},
+			"utterances": []interface{}{map[string]interface{}{
+				"text": "你好。", "start_time": 300, "end_time": 900, "definite": true,
+				"additions": map[string]interface{}{"speaker": "1"},
+				"words": []interface{}{
+					map[string]interface{}{"text": "你", "start_time": 300, "end_time": 560, "blank_duration": 0, "confidence": 0.9},
+					map[string]interface{}{"text": "好", "start_time": 600, "end_time": 900, "blank_duration": 40},
+				},
+			}},
+			"unknown_field": "kept in raw",
+		},
+	}
+
+	resp := parseResponse(asrServerFrame(-3, true, payload))
+	require.NotNil(t, resp.Result)
+	assert.True(t, resp.IsLastPackage)
+	assert.Equal(t, 2100, resp.Result.AudioInfo.Duration)
+	assert.Equal(t, "你好。", resp.Result.Text())
+	assert.Equal(t, "abc", resp.Result.Result.Additions["log_id"])
+
+	utterances := resp.Result.Utterances()
+	require.Len(t, utterances, 1)
+	u := utterances[0]
+	assert.True(t, u.Definite)
+	assert.Equal(t, 300*time.Millisecond, u.Start())
+	assert.Equal(t, 900*time.Millisecond, u.End())
+	assert.Equal(t, "1", u.Additions["speaker"])
+	assert.Equal(t, []AsrWord{
+		{Text: "你", StartTime: 300, EndTime: 560, Confidence: 0.9},
+		{Text: "好", StartTime: 600, EndTime: 900, BlankDuration: 40},
+	}, u.Words)
+
+	var raw map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Result.Raw, &raw))
+	assert.Equal(t, "kept in raw", raw["result"]["unknown_field"])
+
+	// 未返回结果时访问方法不会panic
+	var empty *AsrResult
+	assert.Equal(t, "", empty.Text())
+	assert.Nil(t, empty.Utterances())
+}
@@ -1,8 +1,23 @@
 package cloudsdk
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestASRClient_StreamRecognition(t *testing.T) {
@@ -36,3 +51,322 @@ func TestASRClient_StreamRecognition(t *testing.T) {
 
 	t.Logf("Recognition result: %+v\n", result)
 }
+
+// fakeAsrServer 模拟bigmodel流式识别服务，记录收到的请求和音频分包
+type fakeAsrServer struct {
+	*httptest.Server
+	t *testing.T
+
+	mu      sync.Mutex
+	request map[string]interface{}
+	chunks  [][]byte
+	seqs    []int32
+
+	// result 根据本连接已收到的音频生成响应中的result字段，为空时返回收到的字节数
+	result func(audio []byte, last bool) interface{}
+	// onlyFinal 为true时只在收到最后一包后响应，模拟不与上传同步的服务端
+	onlyFinal bool
+	// errorCode 不为0时对第一个音频包返回错误响应
+	errorCode int
+	// fail 不为空时根据本连接已收到的音频决定是否返回错误响应
+	fail func(audio []byte) bool
+}
+
+func newFakeAsrServer(t *testing.T) *fakeAsrServer {
+	s := &fakeAsrServer{t: t}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// WsURL 返回ws协议的服务地址
+func (s *fakeAsrServer) WsURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// Audio 返回收到的全部音频
+func (s *fakeAsrServer) Audio() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return bytes.Join(s.chunks, nil)
+}
+
+func (s *fakeAsrServer) handle(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.t.Errorf("upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	var session []byte // 本连接收到的音频
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		messageType := msg[1] >> 4
+		seq := int32(binary.BigEndian.Uint32(msg[4:8]))
+		payload, err := gzipDecompress(msg[12:])
+		if err != nil {
+			payload = nil // 空分包
+		}
+
+		s.mu.Lock()
+		var resp interface{}
+		last := seq < 0
+		if messageType == FULL_CLIENT_REQUEST {
+			json.Unmarshal(payload, &s.request)
+			resp = map[string]interface{}{}
+		} else {
+			s.chunks = append(s.chunks, payload)
+			s.seqs = append(s.seqs, seq)
+			session = append(session, payload...)
+			audio := session
+			var result interface{} = map[string]interface{}{"text": fmt.Sprintf("%d bytes", len(audio))}
+			if s.result != nil {
+				result = s.result(audio, last)
+			}
+			resp = map[string]interface{}{"result": result}
+		}
+		onlyFinal, errorCode := s.onlyFinal, s.errorCode
+		if messageType == AUDIO_ONLY_REQUEST && s.fail != nil && s.fail(session) {
+			errorCode = 55000000
+		}
+		s.mu.Unlock()
+
+		frame := asrServerFrame(seq, last, resp)
+		if messageType == AUDIO_ONLY_REQUEST && errorCode != 0 {
+			frame = asrErrorFrame(errorCode, "invalid audio")
+		} else if messageType == AUDIO_ONLY_REQUEST && onlyFinal && !last {
+			continue
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+			return
+		}
+	}
+}
+
+// asrServerFrame 构造一个gzip压缩的JSON服务端响应
+func asrServerFrame(seq int32, last bool, payload interface{}) []byte {
+	data, _ := json.Marshal(payload)
+	compressed, _ := gzipCompress(data)
+	flags := byte(POS_SEQUENCE)
+	if last {
+		flags = NEG_WITH_SEQUENCE
+	}
+	frame := generateHeader(FULL_SERVER_RESPONSE, flags, JSON, GZIP_COMPRESSION, 0x00)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(seq))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(compressed)))
+	return append(frame, compressed...)
+}
+
+// asrErrorFrame 构造一个服务端错误响应
+func asrErrorFrame(code int, message string) []byte {
+	payload, _ := json.Marshal(map[string]string{"error": message})
+	frame := generateHeader(SERVER_ERROR_RESPONSE, NO_SEQUENCE, JSON, NO_COMPRESSION, 0x00)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(code))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+func newTestAsrClient(s *fakeAsrServer, format string) *AsrWsClient {
+	return NewAsrWsClient(&AsrConfig{
+		SegDuration: 100,
+		WsURL:       s.WsURL(),
+		UID:         "test",
+		Format:      format,
+		Rate:        16000,
+		Bits:        16,
+		Channel:     1,
+		Codec:       "raw",
+	})
+}
+
+func TestAsrWsClient_RecognizeReader(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "pcm")
+
+	// 通过管道逐步写入，模拟实时音频来源
+	audio := bytes.Repeat([]byte{1, 2, 3, 4, 5}, 20000)
+	pr, pw := io.Pipe()
+	go func() {
+		for data := audio; len(data) > 0; {
+			n := min(3000, len(data))
+			pw.Write(data[:n])
+			data = data[n:]
+		}
+		pw.Close()
+	}()
+
+	resp, err := client.RecognizeReader(context.Background(), pr)
+	require.NoError(t, err)
+	assert.True(t, resp.IsLastPackage)
+	assert.Equal(t, audio, server.Audio())
+
+	// pcm 每包 16000*2*1*100/1000 = 3200 字节
+	segment := 3200
+	for i, chunk := range server.chunks[:len(server.chunks)-1] {
+		assert.Len(t, chunk, segment, "chunk %d", i)
+	}
+	lastSeq := server.seqs[len(server.seqs)-1]
+	assert.Equal(t, int32(-(len(server.seqs) + 1)), lastSeq)
+	assert.Equal(t, "bigmodel", server.request["request"].(map[string]interface{})["model_name"])
+}
+
+func TestAsrWsClient_WAVChunkSize(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "wav")
+	client.config.SegDuration = 200
+
+	// 16kHz单声道16位，每包200ms即6400字节，与baseline的WAV分包大小一致
+	a := &PCMAudio{SampleRate: 16000, Channels: 1, Samples: make([]int16, 16000)}
+	_, err := client.RecognizeReader(context.Background(), bytes.NewReader(a.WAV()))
+	require.NoError(t, err)
+
+	require.Len(t, server.chunks, 6)
+	for i, chunk := range server.chunks[:5] {
+		assert.Len(t, chunk, 6400, "chunk %d", i)
+	}
+	assert.Empty(t, server.chunks[5])
+}
+
+func TestAsrWsClient_RecognizeReaderDefaultFormat(t *testing.T) {
+	server := newFakeAsrServer(t)
+	// 未设置采样率、位深和声道数时按16kHz单声道16位发送
+	client := NewAsrWsClient(&AsrConfig{SegDuration: 100, WsURL: server.WsURL(), Format: "pcm"})
+
+	audio := make([]byte, 6400)
+	_, err := client.RecognizeReader(context.Background(), bytes.NewReader(audio))
+	require.NoError(t, err)
+	assert.Equal(t, audio, server.Audio())
+	assert.Len(t, server.chunks[0], 3200)
+
+	req := server.request["audio"].(map[string]interface{})
+	assert.Equal(t, float64(16000), req["sample_rate"])
+	assert.Equal(t, float64(16), req["bits"])
+	assert.Equal(t, float64(1), req["channel"])
+}
+
+func TestAsrWsClient_RecognizeReaderCancel(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "pcm")
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		pw.Write(make([]byte, 6400))
+		cancel()
+	}()
+
+	_, err := client.RecognizeReader(ctx, pr)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAsrWsClient_RecognizeReaderWAV(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "wav")
+
+	a := sineTone(16000, 16000, 440, 5000)
+	resp, err := client.RecognizeReader(context.Background(), bytes.NewReader(extensibleWAV(a, wavFormatPCM)))
+	require.NoError(t, err)
+	assert.True(t, resp.IsLastPackage)
+
+	// 只发送data块中的PCM，文件头和尾部的其他块都被去掉
+	assert.Equal(t, a.Bytes(), server.Audio())
+	audio := server.request["audio"].(map[string]interface{})
+	assert.Equal(t, "pcm", audio["format"])
+	assert.Equal(t, float64(16000), audio["sample_rate"])
+
+	_, err = client.RecognizeReader(context.Background(), bytes.NewReader(extensibleWAV(a, wavFormatMuLaw)))
+	assert.ErrorContains(t, err, "mu-law")
+}
+
+func TestAsrWsClient_OutOfLockstepResponses(t *testing.T) {
+	server := newFakeAsrServer(t)
+	server.onlyFinal = true
+	client := newTestAsrClient(server, "pcm")
+
+	// 服务端在最后一包之前不响应，上传不能被阻塞
+	audio := make([]byte, 6400*10)
+	var responses int
+	resp, err := client.RecognizeStreaming(context.Background(), &RecognizeRequest{Audio: bytes.NewReader(audio)}, func(*RecognizeResult) {
+		responses++
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.IsFinal)
+	assert.Equal(t, "64000 bytes", resp.Text)
+	assert.Equal(t, 1, responses)
+	assert.Len(t, server.Audio(), len(audio))
+}
+
+func TestAsrWsClient_ServerError(t *testing.T) {
+	server := newFakeAsrServer(t)
+	server.errorCode = 45000001
+	client := newTestAsrClient(server, "pcm")
+
+	// 实时来源一直不结束，服务端出错后发送也必须退出
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go func() {
+		for {
+			if _, err := pw.Write(make([]byte, 6400)); err != nil {
+				return
+			}
+		}
+	}()
+
+	_, err := client.RecognizeReader(context.Background(), pr)
+	assert.ErrorContains(t, err, "asr server error 45000001")
+}
+
+func TestAsrWsClient_StreamingPace(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "pcm")
+	client.config.SegDuration = 20
+	client.config.Streaming = true
+
+	// 20个640字节的整包按20ms节奏发送，至少需要400ms；非实时模式则不等待
+	audio := make([]byte, 640*20+10)
+	start := time.Now()
+	_, err := client.RecognizeReader(context.Background(), bytes.NewReader(audio))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+
+	client.config.Streaming = false
+	start = time.Now()
+	_, err = client.RecognizeReader(context.Background(), bytes.NewReader(audio))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestAsrWsClient_StreamingPacePCM(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "pcm")
+	client.config.SegDuration = 50
+	client.config.Streaming = true
+
+	// 500ms的16kHz音频应按实时速度发送，而不是两倍速
+	audio := make([]byte, 16000)
+	start := time.Now()
+	_, err := client.RecognizeReader(context.Background(), bytes.NewReader(audio))
+	require.NoError(t, err)
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 450*time.Millisecond)
+	assert.Less(t, elapsed, 900*time.Millisecond)
+}
+
+func TestFixedChunks_Duration(t *testing.T) {
+	next := fixedChunks(bytes.NewReader(make([]byte, 4000)), 3200, 32000)
+	chunk, d, err := next()
+	require.NoError(t, err)
+	assert.Len(t, chunk, 3200)
+	assert.Equal(t, 100*time.Millisecond, d)
+
+	chunk, d, err = next()
+	assert.Equal(t, io.EOF, err)
+	assert.Len(t, chunk, 800)
+	assert.Equal(t, 25*time.Millisecond, d)
+}
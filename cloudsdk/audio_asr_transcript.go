@@ -0,0 +1,82 @@
+package cloudsdk
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// TranscriptEvent 流式识别过程中的一条转写事件
+type TranscriptEvent struct {
+	Text  string        // 分句文本，服务端未返回分句时为当前整段文本
+	Final bool          // true表示该分句已确定，后续不会再变化；false为中间假设
+	Start time.Duration // 分句在音频中的起止时间，服务端未返回时为0
+	End   time.Duration
+}
+
+// transcriber 将bigmodel的累计结果拆分为中间假设和确定的分句
+// 服务端每个响应都会带上全部分句，已确定的分句只发出一次，同一分句的中间假设文本不变时不重复发出
+type transcriber struct {
+	onEvent func(TranscriptEvent)
+	final   int            // 已发出的确定分句数
+	interim map[int]string // 各分句上一次发出的中间假设，下标为分句序号，-1为未分句时的整段文本
+}
+
+// wholeText 服务端未返回分句时整段文本使用的序号
+const wholeText = -1
+
+func (t *transcriber) emitInterim(i int, e TranscriptEvent) {
+	if e.Text == "" || e.Text == t.interim[i] {
+		return
+	}
+	if t.interim == nil {
+		t.interim = make(map[int]string)
+	}
+	t.interim[i] = e.Text
+	t.onEvent(e)
+}
+
+func (t *transcriber) emitFinal(i int, e TranscriptEvent) {
+	delete(t.interim, i)
+	if e.Text == "" {
+		return
+	}
+	t.onEvent(e)
+}
+
+// handle 处理一个服务端响应
+func (t *transcriber) handle(resp *Response) {
+	utterances := resp.Result.Utterances()
+	if len(utterances) == 0 {
+		text := resp.Result.Text()
+		if resp.IsLastPackage {
+			t.emitFinal(wholeText, TranscriptEvent{Text: text, Final: true})
+		} else {
+			t.emitInterim(wholeText, TranscriptEvent{Text: text})
+		}
+		return
+	}
+
+	for i := t.final; i < len(utterances); i++ {
+		u := utterances[i]
+		e := TranscriptEvent{Text: u.Text, Start: u.Start(), End: u.End()}
+		// 最后一个响应中未标记确定的分句也视为最终结果
+		if u.Definite || resp.IsLastPackage {
+			e.Final = true
+			t.emitFinal(i, e)
+			t.final = i + 1
+			continue
+		}
+		t.emitInterim(i, e)
+	}
+}
+
+// RecognizeTranscripts 从r中边读边识别，每收到新的中间假设或确定的分句都会回调onEvent，适用于实时字幕
+// 中间假设可能被后续事件修正，Final事件的文本不会再变化；总是以AsrResultFull方式请求带分句的累计结果
+func (c *AsrWsClient) RecognizeTranscripts(ctx context.Context, r io.Reader, onEvent func(TranscriptEvent)) (*Response, error) {
+	opts := c.config.Options
+	opts.ResultType = AsrResultFull
+	opts.ShowUtterances = true
+	t := &transcriber{onEvent: onEvent}
+	return c.WithOptions(opts).recognizeReader(ctx, r, t.handle)
+}
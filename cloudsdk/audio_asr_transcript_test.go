@@ -0,0 +1,118 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func utteranceResult(text string, utterances ...map[string]interface{}) map[string]interface{} {
+	list := make([]interface{}, len(utterances))
+	for i, u := range utterances {
+		list[i] = u
+	}
+	return map[string]interface{}{"text": text, "utterances": list}
+}
+
+func utterance(text string, start, end int, definite bool) map[string]interface{} {
+	return map[string]interface{}{"text": text, "start_time": float64(start), "end_time": float64(end), "definite": definite}
+}
+
+// resultResponse 将result字段编码为服务端响应
+func resultResponse(t *testing.T, last bool, result map[string]interface{}) *Response {
+	payload, err := json.Marshal(map[string]interface{}{"result": result})
+	require.NoError(t, err)
+	parsed, err := parseAsrResult(payload)
+	require.NoError(t, err)
+	return &Response{IsLastPackage: last, Result: parsed}
+}
+
+func TestTranscriber_Utterances(t *testing.T) {
+	var events []TranscriptEvent
+	tr := &transcriber{onEvent: func(e TranscriptEvent) { events = append(events, e) }}
+
+	responses := []map[string]interface{}{
+		utteranceResult("你好", utterance("你好", 0, 500, false)),
+		utteranceResult("你好", utterance("你好", 0, 500, false)),
+		utteranceResult("你好世界", utterance("你好世界。", 0, 900, true)),
+		utteranceResult("你好世界。今天", utterance("你好世界。", 0, 900, true), utterance("今天", 1000, 1300, false)),
+	}
+	for _, r := range responses {
+		tr.handle(resultResponse(t, false, r))
+	}
+	last := utteranceResult("你好世界。今天天气好", utterance("你好世界。", 0, 900, true), utterance("今天天气好", 1000, 1800, false))
+	tr.handle(resultResponse(t, true, last))
+
+	assert.Equal(t, []TranscriptEvent{
+		{Text: "你好", Start: 0, End: 500 * time.Millisecond},
+		{Text: "你好世界。", Final: true, End: 900 * time.Millisecond},
+		{Text: "今天", Start: time.Second, End: 1300 * time.Millisecond},
+		{Text: "今天天气好", Final: true, Start: time.Second, End: 1800 * time.Millisecond},
+	}, events)
+}
+
+func TestTranscriber_MultipleInterim(t *testing.T) {
+	var events []TranscriptEvent
+	tr := &transcriber{onEvent: func(e TranscriptEvent) { events = append(events, e) }}
+
+	// 同一响应中有多个未确定分句时，各分句文本不变就不重复发出
+	r := utteranceResult("一二", utterance("一", 0, 300, false), utterance("二", 400, 700, false))
+	tr.handle(resultResponse(t, false, r))
+	tr.handle(resultResponse(t, false, r))
+	r = utteranceResult("一二三", utterance("一", 0, 300, false), utterance("二三", 400, 900, false))
+	tr.handle(resultResponse(t, false, r))
+
+	assert.Equal(t, []TranscriptEvent{
+		{Text: "一", End: 300 * time.Millisecond},
+		{Text: "二", Start: 400 * time.Millisecond, End: 700 * time.Millisecond},
+		{Text: "二三", Start: 400 * time.Millisecond, End: 900 * time.Millisecond},
+	}, events)
+}
+
+func TestTranscriber_TextOnly(t *testing.T) {
+	var events []TranscriptEvent
+	tr := &transcriber{onEvent: func(e TranscriptEvent) { events = append(events, e) }}
+
+	tr.handle(resultResponse(t, false, map[string]interface{}{"text": "你"}))
+	tr.handle(&Response{})
+	tr.handle(resultResponse(t, true, map[string]interface{}{"text": "你好"}))
+
+	assert.Equal(t, []TranscriptEvent{{Text: "你"}, {Text: "你好", Final: true}}, events)
+}
+
+func TestAsrWsClient_RecognizeTranscripts(t *testing.T) {
+	server := newFakeAsrServer(t)
+	// 每收到6400字节确定一个分句，其余为中间假设
+	server.result = func(audio []byte, last bool) interface{} {
+		var utterances []map[string]interface{}
+		full := len(audio) / 6400
+		for i := 0; i < full; i++ {
+			utterances = append(utterances, utterance(string(rune('a'+i)), i*200, (i+1)*200, true))
+		}
+		if rest := len(audio) % 6400; rest > 0 {
+			utterances = append(utterances, utterance("partial", full*200, full*200+rest/32, false))
+		}
+		return utteranceResult("", utterances...)
+	}
+	client := newTestAsrClient(server, "pcm")
+
+	var events []TranscriptEvent
+	_, err := client.RecognizeTranscripts(context.Background(), bytes.NewReader(make([]byte, 6400*3+3200)), func(e TranscriptEvent) {
+		events = append(events, e)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, true, server.request["request"].(map[string]interface{})["show_utterances"])
+
+	var finals []string
+	for _, e := range events {
+		if e.Final {
+			finals = append(finals, e.Text)
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c", "partial"}, finals)
+}
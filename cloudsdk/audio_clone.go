@@ -0,0 +1,503 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	megaTTSBaseURL    = "https://openspeech.bytedance.com"
+	megaTTSResourceID = "volc.megatts.voiceclone"
+	openAPIURL        = "https://open.volcengineapi.com"
+	openAPIVersion    = "2023-11-07"
+	openAPIRegion     = "cn-north-1"
+	openAPIService    = "speech_saas_prod"
+
+	// ClonedVoiceCluster 复刻音色合成时使用的TTS集群
+	ClonedVoiceCluster = "volcano_icl"
+)
+
+// SpeakerStatus 声音复刻音色的训练状态
+type SpeakerStatus int
+
+const (
+	SpeakerNotFound SpeakerStatus = iota
+	SpeakerTraining
+	SpeakerSuccess
+	SpeakerFailed
+	SpeakerActive
+)
+
+func (s SpeakerStatus) String() string {
+	switch s {
+	case SpeakerNotFound:
+		return "NotFound"
+	case SpeakerTraining:
+		return "Training"
+	case SpeakerSuccess:
+		return "Success"
+	case SpeakerFailed:
+		return "Failed"
+	case SpeakerActive:
+		return "Active"
+	}
+	return fmt.Sprintf("Unknown(%d)", int(s))
+}
+
+// Client 声音复刻(megaTTS)客户端
+// 上传训练音频和查询状态使用appid+token鉴权；激活和列出音色属于火山引擎OpenAPI，
+// 需要通过WithOpenAPICredentials设置AccessKey/SecretKey
+type Client struct {
+	appid      string
+	token      string
+	accessKey  string
+	secretKey  string
+	baseURL    string
+	openAPIURL string
+	httpClient *http.Client
+
+	pollInterval    time.Duration // WaitForSpeaker首次轮询间隔，默认2s
+	maxPollInterval time.Duration // WaitForSpeaker最大轮询间隔，默认30s
+
+	qualityCheck *QualityThresholds // 非空时Train上传前先检查wav/pcm音频质量
+	pcmFormat    *PCMFormat         // pcm训练音频的采样格式，质量检查需要
+}
+
+// BaseResp 声音复刻接口的通用状态
+type BaseResp struct {
+	StatusCode    int    `json:"StatusCode"`
+	StatusMessage string `json:"StatusMessage"`
+}
+
+// TrainResult 上传训练音频的响应体
+type TrainResult struct {
+	BaseResp  BaseResp `json:"BaseResp"`
+	SpeakerID string   `json:"speaker_id"`
+}
+
+// TrainResponse 上传训练音频的响应
+type TrainResponse struct {
+	StatusCode int
+	Body       TrainResult
+}
+
+// SpeakerStatusResult 查询训练状态的响应体
+type SpeakerStatusResult struct {
+	BaseResp   BaseResp      `json:"BaseResp"`
+	SpeakerID  string        `json:"speaker_id"`
+	Status     SpeakerStatus `json:"status"`
+	CreateTime int64         `json:"create_time"`
+	Version    string        `json:"version"`
+	DemoAudio  string        `json:"demo_audio"`
+}
+
+// StatusResponse 查询训练状态的响应
+type StatusResponse struct {
+	StatusCode int
+	Body       SpeakerStatusResult
+}
+
+// ResponseMetadata 火山引擎OpenAPI的通用响应元数据
+type ResponseMetadata struct {
+	RequestID string `json:"RequestId"`
+	Action    string `json:"Action"`
+	Version   string `json:"Version"`
+	Service   string `json:"Service"`
+	Region    string `json:"Region"`
+	Error     *struct {
+		Code    string `json:"Code"`
+		Message string `json:"Message"`
+	} `json:"Error,omitempty"`
+}
+
+// SpeakerInfo OpenAPI返回的音色信息
+type SpeakerInfo struct {
+	SpeakerID              string `json:"SpeakerID"`
+	InstanceNO             string `json:"InstanceNO"`
+	State                  string `json:"State"` // Unknown/Training/Success/Active/Expired/Reclaimed
+	IsActivable            bool   `json:"IsActivable"`
+	Alias                  string `json:"Alias"`
+	Version                string `json:"Version"`
+	DemoAudio              string `json:"DemoAudio"`
+	CreateTime             int64  `json:"CreateTime"`
+	ExpireTime             int64  `json:"ExpireTime"`
+	AvailableTrainingTimes int    `json:"AvailableTrainingTimes"`
+}
+
+// ListSpeakersResponse 列出音色的响应
+type ListSpeakersResponse struct {
+	StatusCode int
+	Body       struct {
+		ResponseMetadata ResponseMetadata `json:"ResponseMetadata"`
+		Result           struct {
+			Statuses []SpeakerInfo `json:"Statuses"`
+		} `json:"Result"`
+	}
+}
+
+// ActivateResponse 激活音色的响应
+type ActivateResponse struct {
+	StatusCode int
+	Body       struct {
+		ResponseMetadata ResponseMetadata `json:"ResponseMetadata"`
+	}
+}
+
+// NewClient 创建声音复刻客户端
+func NewClient(appid, token string) *Client {
+	return &Client{
+		appid:      appid,
+		token:      token,
+		baseURL:    megaTTSBaseURL,
+		openAPIURL: openAPIURL,
+		httpClient: &http.Client{Timeout: time.Minute},
+
+		pollInterval:    2 * time.Second,
+		maxPollInterval: 30 * time.Second,
+	}
+}
+
+// WithHTTPClient 替换默认的HTTP客户端
+func (c *Client) WithHTTPClient(hc *http.Client) *Client {
+	c.httpClient = hc
+	return c
+}
+
+// WithBaseURL 替换openspeech接口地址，用于私有化部署或测试
+func (c *Client) WithBaseURL(baseURL string) *Client {
+	c.baseURL = strings.TrimRight(baseURL, "/")
+	return c
+}
+
+// WithOpenAPICredentials 设置调用火山引擎OpenAPI所需的AccessKey/SecretKey
+func (c *Client) WithOpenAPICredentials(accessKey, secretKey string) *Client {
+	c.accessKey = accessKey
+	c.secretKey = secretKey
+	return c
+}
+
+// WithOpenAPIURL 替换OpenAPI接口地址，用于测试
+func (c *Client) WithOpenAPIURL(u string) *Client {
+	c.openAPIURL = strings.TrimRight(u, "/")
+	return c
+}
+
+// WithPollInterval 设置WaitForSpeaker的轮询间隔，每次轮询后间隔翻倍直到max
+func (c *Client) WithPollInterval(initial, max time.Duration) *Client {
+	c.pollInterval = initial
+	c.maxPollInterval = max
+	return c
+}
+
+// WithQualityCheck 开启上传前的本地质量检查，只能分析wav/pcm文件，其他格式的训练音频会返回错误
+// pcm文件需要同时通过WithPCMFormat说明采样格式
+func (c *Client) WithQualityCheck(th QualityThresholds) *Client {
+	c.qualityCheck = &th
+	return c
+}
+
+// WithPCMFormat 设置pcm训练音频的采样率、声道数和位深，供质量检查使用
+func (c *Client) WithPCMFormat(format PCMFormat) *Client {
+	c.pcmFormat = &format
+	return c
+}
+
+// Train 上传训练音频，开始训练speakerID对应的音色
+func (c *Client) Train(ctx context.Context, speakerID, audioPath string) (*TrainResponse, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(audioPath)), ".")
+	if format == "" {
+		return nil, fmt.Errorf("cannot detect audio format of %s", audioPath)
+	}
+	if c.qualityCheck != nil {
+		var report *AudioQualityReport
+		var err error
+		if format == "pcm" && c.pcmFormat != nil {
+			report, err = AnalyzeTrainingPCM(audioPath, *c.pcmFormat)
+		} else {
+			report, err = AnalyzeTrainingAudio(audioPath)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("analyze training audio failed: %v", err)
+		}
+		if err := report.Check(*c.qualityCheck); err != nil {
+			return nil, err
+		}
+	}
+
+	audio, err := os.ReadFile(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio file: %v", err)
+	}
+
+	payload := map[string]interface{}{
+		"appid":      c.appid,
+		"speaker_id": speakerID,
+		"audios": []map[string]string{{
+			"audio_bytes":  base64.StdEncoding.EncodeToString(audio),
+			"audio_format": format,
+		}},
+		"source":     2,
+		"language":   0,
+		"model_type": 1,
+	}
+
+	resp := &TrainResponse{}
+	resp.StatusCode, err = c.postMegaTTS(ctx, "/api/v1/mega_tts/audio/upload", payload, &resp.Body)
+	if err != nil {
+		return resp, err
+	}
+	if err := checkBaseResp(resp.Body.BaseResp); err != nil {
+		return resp, fmt.Errorf("train failed: %v", err)
+	}
+	return resp, nil
+}
+
+// Status 查询speakerID的训练状态
+func (c *Client) Status(ctx context.Context, speakerID string) (*StatusResponse, error) {
+	payload := map[string]string{
+		"appid":      c.appid,
+		"speaker_id": speakerID,
+	}
+
+	resp := &StatusResponse{}
+	var err error
+	resp.StatusCode, err = c.postMegaTTS(ctx, "/api/v1/mega_tts/status", payload, &resp.Body)
+	if err != nil {
+		return resp, err
+	}
+	if err := checkBaseResp(resp.Body.BaseResp); err != nil {
+		return resp, fmt.Errorf("query status failed: %v", err)
+	}
+	return resp, nil
+}
+
+// Activate 激活训练完成的音色，激活后音色不可再次训练
+func (c *Client) Activate(ctx context.Context, speakerIDs ...string) (*ActivateResponse, error) {
+	if len(speakerIDs) == 0 {
+		return nil, errors.New("no speaker id to activate")
+	}
+	payload := map[string]interface{}{
+		"AppID":      c.appid,
+		"SpeakerIDs": speakerIDs,
+	}
+
+	resp := &ActivateResponse{}
+	var err error
+	resp.StatusCode, err = c.postOpenAPI(ctx, "ActivateMegaTTSTrainStatus", payload, &resp.Body)
+	if err != nil {
+		return resp, err
+	}
+	if err := checkResponseMetadata(resp.Body.ResponseMetadata); err != nil {
+		return resp, fmt.Errorf("activate failed: %v", err)
+	}
+	return resp, nil
+}
+
+// ListSpeakers 列出账号下的音色，指定speakerIDs时只返回这些音色
+func (c *Client) ListSpeakers(ctx context.Context, speakerIDs ...string) (*ListSpeakersResponse, error) {
+	payload := map[string]interface{}{
+		"AppID":      c.appid,
+		"SpeakerIDs": speakerIDs,
+	}
+	if speakerIDs == nil {
+		payload["SpeakerIDs"] = []string{}
+	}
+
+	resp := &ListSpeakersResponse{}
+	var err error
+	resp.StatusCode, err = c.postOpenAPI(ctx, "ListMegaTTSTrainStatus", payload, &resp.Body)
+	if err != nil {
+		return resp, err
+	}
+	if err := checkResponseMetadata(resp.Body.ResponseMetadata); err != nil {
+		return resp, fmt.Errorf("list speakers failed: %v", err)
+	}
+	return resp, nil
+}
+
+func checkBaseResp(b BaseResp) error {
+	if b.StatusCode != 0 {
+		return fmt.Errorf("code %d: %s", b.StatusCode, b.StatusMessage)
+	}
+	return nil
+}
+
+func checkResponseMetadata(m ResponseMetadata) error {
+	if m.Error != nil && m.Error.Code != "" {
+		return fmt.Errorf("%s: %s", m.Error.Code, m.Error.Message)
+	}
+	return nil
+}
+
+// postMegaTTS 调用openspeech上的声音复刻接口
+func (c *Client) postMegaTTS(ctx context.Context, path string, payload, out interface{}) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer;%s", c.token))
+	req.Header.Set("Resource-Id", megaTTSResourceID)
+
+	return c.do(req, out)
+}
+
+// postOpenAPI 调用火山引擎OpenAPI，请求使用HMAC-SHA256签名
+func (c *Client) postOpenAPI(ctx context.Context, action string, payload, out interface{}) (int, error) {
+	if c.accessKey == "" || c.secretKey == "" {
+		return 0, errors.New("openapi credentials not set, call WithOpenAPICredentials first")
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	query := url.Values{"Action": {action}, "Version": {openAPIVersion}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.openAPIURL+"/?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.signOpenAPIRequest(req, body, time.Now().UTC())
+
+	return c.do(req, out)
+}
+
+// signOpenAPIRequest 按火山引擎签名规范为请求添加Authorization头
+func (c *Client) signOpenAPIRequest(req *http.Request, body []byte, now time.Time) {
+	xDate := now.Format("20060102T150405Z")
+	shortDate := xDate[:8]
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Date", xDate)
+	req.Header.Set("X-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-content-sha256;x-date"
+	canonicalHeaders := fmt.Sprintf("content-type:%s\nhost:%s\nx-content-sha256:%s\nx-date:%s\n",
+		req.Header.Get("Content-Type"), req.URL.Host, payloadHash, xDate)
+	canonicalQuery := strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20")
+	canonicalRequest := strings.Join([]string{
+		req.Method, "/", canonicalQuery, canonicalHeaders, signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/request", shortDate, openAPIRegion, openAPIService)
+	stringToSign := strings.Join([]string{"HMAC-SHA256", xDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte(c.secretKey), shortDate)
+	key = hmacSHA256(key, openAPIRegion)
+	key = hmacSHA256(key, openAPIService)
+	key = hmacSHA256(key, "request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// do 发送请求并将JSON响应解析到out，返回HTTP状态码
+func (c *Client) do(req *http.Request, out interface{}) (int, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("unexpected http status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %v", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// SpeakerError 音色训练进入失败等终止状态时返回的错误
+type SpeakerError struct {
+	SpeakerID string
+	Status    SpeakerStatus
+	Reason    string
+}
+
+func (e *SpeakerError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("speaker %s %s: %s", e.SpeakerID, e.Status, e.Reason)
+	}
+	return fmt.Sprintf("speaker %s %s", e.SpeakerID, e.Status)
+}
+
+// WaitForSpeaker 轮询训练状态直到音色可用(Success或Active)
+// 训练失败或音色不存在(包括HTTP 404)时返回*SpeakerError；鉴权失败等其他4xx错误和业务错误原样返回；
+// 网络错误和服务端5xx错误会继续重试，直到ctx结束，此时返回的错误包装了ctx.Err()
+func (c *Client) WaitForSpeaker(ctx context.Context, speakerID string) (*SpeakerStatusResult, error) {
+	var result *SpeakerStatusResult
+	err := pollWithBackoff(ctx, c.pollInterval, c.maxPollInterval, func() (bool, error) {
+		resp, err := c.Status(ctx, speakerID)
+		switch {
+		case err != nil && resp != nil && resp.StatusCode == http.StatusNotFound:
+			return false, &SpeakerError{SpeakerID: speakerID, Status: SpeakerNotFound, Reason: err.Error()}
+		case err != nil && resp != nil && !retryableStatus(resp.StatusCode):
+			return false, err
+		case err != nil:
+			log.Printf("query speaker %s status failed, retrying: %v", speakerID, err)
+			return false, nil
+		}
+		switch resp.Body.Status {
+		case SpeakerSuccess, SpeakerActive:
+			result = &resp.Body
+			return true, nil
+		case SpeakerFailed, SpeakerNotFound:
+			return false, &SpeakerError{
+				SpeakerID: speakerID,
+				Status:    resp.Body.Status,
+				Reason:    resp.Body.BaseResp.StatusMessage,
+			}
+		}
+		return false, nil
+	})
+	if err != nil && err == ctx.Err() {
+		return nil, fmt.Errorf("wait for speaker %s: %w", speakerID, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SpeakerTTSClient 返回使用复刻音色的TTS客户端，cluster和voice_type已自动设置
+func (c *Client) SpeakerTTSClient(speakerID string) *TTSWsClient {
+	return NewTTSWsClient(c.appid, c.token, ClonedVoiceCluster).WithVoiceType(speakerID)
+}
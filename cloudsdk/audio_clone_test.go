@@ -2,8 +2,13 @@ package cloudsdk
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -33,3 +38,157 @@ func TestClient_Train(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	t.Logf("Response: %+v", resp.Body)
 }
+
+// newFakeMegaTTSServer 模拟声音复刻接口，记录收到的请求体
+func newFakeMegaTTSServer(t *testing.T, handler func(path string, body map[string]interface{}) interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); !assert.NoError(t, err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		path := r.URL.Path
+		if action := r.URL.Query().Get("Action"); action != "" {
+			path = action
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(handler(path, body))
+	}))
+}
+
+func TestClient_TrainUpload(t *testing.T) {
+	audioPath := filepath.Join(t.TempDir(), "sample.wav")
+	require.NoError(t, os.WriteFile(audioPath, []byte("RIFFdata"), 0644))
+
+	server := newFakeMegaTTSServer(t, func(path string, body map[string]interface{}) interface{} {
+		assert.Equal(t, "/api/v1/mega_tts/audio/upload", path)
+		assert.Equal(t, "S_test", body["speaker_id"])
+		audios := body["audios"].([]interface{})
+		audio := audios[0].(map[string]interface{})
+		assert.Equal(t, "wav", audio["audio_format"])
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("RIFFdata")), audio["audio_bytes"])
+		return map[string]interface{}{
+			"BaseResp":   map[string]interface{}{"StatusCode": 0},
+			"speaker_id": "S_test",
+		}
+	})
+	defer server.Close()
+
+	client := NewClient("appid", "token").WithBaseURL(server.URL)
+	resp, err := client.Train(context.Background(), "S_test", audioPath)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "S_test", resp.Body.SpeakerID)
+}
+
+func TestClient_StatusError(t *testing.T) {
+	server := newFakeMegaTTSServer(t, func(path string, body map[string]interface{}) interface{} {
+		assert.Equal(t, "/api/v1/mega_tts/status", path)
+		return map[string]interface{}{
+			"BaseResp":   map[string]interface{}{"StatusCode": 1001, "StatusMessage": "speaker not found"},
+			"speaker_id": body["speaker_id"],
+		}
+	})
+	defer server.Close()
+
+	client := NewClient("appid", "token").WithBaseURL(server.URL)
+	_, err := client.Status(context.Background(), "S_missing")
+	assert.ErrorContains(t, err, "speaker not found")
+}
+
+func TestClient_ListSpeakers(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		assert.Equal(t, "ListMegaTTSTrainStatus", r.URL.Query().Get("Action"))
+		w.Write([]byte(`{"ResponseMetadata":{"Action":"ListMegaTTSTrainStatus"},
+			"Result":{"Statuses":[{"SpeakerID":"S_1","State":"Success","IsActivable":true}]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("appid", "token").WithOpenAPIURL(server.URL)
+	_, err := client.ListSpeakers(context.Background())
+	assert.ErrorContains(t, err, "openapi credentials not set")
+
+	client.WithOpenAPICredentials("ak", "sk")
+	resp, err := client.ListSpeakers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resp.Body.Result.Statuses, 1)
+	assert.Equal(t, "S_1", resp.Body.Result.Statuses[0].SpeakerID)
+	assert.True(t, strings.HasPrefix(auth, "HMAC-SHA256 Credential=ak/"))
+	assert.Contains(t, auth, "SignedHeaders=content-type;host;x-content-sha256;x-date")
+}
+
+func TestClient_WaitForSpeaker(t *testing.T) {
+	statuses := []SpeakerStatus{SpeakerTraining, SpeakerTraining, SpeakerSuccess}
+	calls := 0
+	server := newFakeMegaTTSServer(t, func(path string, body map[string]interface{}) interface{} {
+		status := statuses[min(calls, len(statuses)-1)]
+		calls++
+		return map[string]interface{}{
+			"BaseResp":   map[string]interface{}{"StatusCode": 0},
+			"speaker_id": body["speaker_id"],
+			"status":     status,
+		}
+	})
+	defer server.Close()
+
+	client := NewClient("appid", "token").WithBaseURL(server.URL).
+		WithPollInterval(time.Millisecond, 4*time.Millisecond)
+	result, err := client.WaitForSpeaker(context.Background(), "S_test")
+	require.NoError(t, err)
+	assert.Equal(t, SpeakerSuccess, result.Status)
+	assert.Equal(t, 3, calls)
+
+	tts := client.SpeakerTTSClient("S_test")
+	input, err := tts.SetupInput("你好", "", optQuery)
+	require.NoError(t, err)
+	assert.Contains(t, string(input), `"cluster":"volcano_icl"`)
+	assert.Contains(t, string(input), `"voice_type":"S_test"`)
+}
+
+func TestClient_WaitForSpeakerFailed(t *testing.T) {
+	server := newFakeMegaTTSServer(t, func(path string, body map[string]interface{}) interface{} {
+		return map[string]interface{}{
+			"BaseResp":   map[string]interface{}{"StatusCode": 0, "StatusMessage": "audio too noisy"},
+			"speaker_id": body["speaker_id"],
+			"status":     SpeakerFailed,
+		}
+	})
+	defer server.Close()
+
+	client := NewClient("appid", "token").WithBaseURL(server.URL)
+	_, err := client.WaitForSpeaker(context.Background(), "S_test")
+	var speakerErr *SpeakerError
+	require.ErrorAs(t, err, &speakerErr)
+	assert.Equal(t, SpeakerFailed, speakerErr.Status)
+	assert.Equal(t, "audio too noisy", speakerErr.Reason)
+}
+
+func TestClient_WaitForSpeakerErrors(t *testing.T) {
+	// 只通过HTTP 404告知音色不存在
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "speaker not found", http.StatusNotFound)
+	}))
+	defer notFound.Close()
+	client := NewClient("appid", "token").WithBaseURL(notFound.URL)
+	_, err := client.WaitForSpeaker(context.Background(), "S_missing")
+	var speakerErr *SpeakerError
+	require.ErrorAs(t, err, &speakerErr)
+	assert.Equal(t, SpeakerNotFound, speakerErr.Status)
+
+	// 一直在训练中时，超时错误可以用errors.Is判断
+	training := newFakeMegaTTSServer(t, func(path string, body map[string]interface{}) interface{} {
+		return map[string]interface{}{
+			"BaseResp":   map[string]interface{}{"StatusCode": 0},
+			"speaker_id": body["speaker_id"],
+			"status":     SpeakerTraining,
+		}
+	})
+	defer training.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	client = NewClient("appid", "token").WithBaseURL(training.URL).WithPollInterval(time.Millisecond, 2*time.Millisecond)
+	_, err = client.WaitForSpeaker(ctx, "S_test")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,149 @@
+package cloudsdk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// PCMFormat 原始PCM音频的采样格式，采样点均为小端交错存储
+type PCMFormat struct {
+	SampleRate int
+	Channels   int
+	Bits       int  // 8位为无符号整数，16/24/32位为有符号整数
+	Float      bool // 为true时采样点是IEEE浮点数，Bits为32或64
+}
+
+func (f PCMFormat) frameSize() int {
+	return f.Channels * f.Bits / 8
+}
+
+func (f PCMFormat) validate() error {
+	if f.SampleRate <= 0 || f.Channels <= 0 {
+		return fmt.Errorf("invalid pcm format: %d channels, %d Hz", f.Channels, f.SampleRate)
+	}
+	switch {
+	case f.Float && (f.Bits == 32 || f.Bits == 64):
+	case !f.Float && (f.Bits == 8 || f.Bits == 16 || f.Bits == 24 || f.Bits == 32):
+	case f.Float:
+		return fmt.Errorf("unsupported pcm encoding: %d-bit float", f.Bits)
+	default:
+		return fmt.Errorf("unsupported pcm encoding: %d-bit integer", f.Bits)
+	}
+	return nil
+}
+
+// is16Bit 是否已经是指定采样率和声道数的16位整数PCM
+func (f PCMFormat) is16Bit(rate, channels int) bool {
+	return !f.Float && f.Bits == 16 && f.SampleRate == rate && f.Channels == channels
+}
+
+// decodeSample 将一个采样点转换为16位整数
+func (f PCMFormat) decodeSample(b []byte) int16 {
+	switch {
+	case f.Float && f.Bits == 32:
+		return clampInt16(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) * 32767)
+	case f.Float:
+		return clampInt16(math.Float64frombits(binary.LittleEndian.Uint64(b)) * 32767)
+	case f.Bits == 8:
+		return int16(int(b[0])-128) << 8
+	case f.Bits == 16:
+		return int16(binary.LittleEndian.Uint16(b))
+	case f.Bits == 24:
+		return int16(b[1]) | int16(int8(b[2]))<<8
+	default:
+		return int16(binary.LittleEndian.Uint32(b) >> 16)
+	}
+}
+
+// PCMFormat 返回WAV数据对应的PCM格式，压缩编码等无法直接转换的格式返回错误
+func (f *WAVFormat) PCMFormat() (PCMFormat, error) {
+	p := PCMFormat{SampleRate: f.SampleRate, Channels: f.Channels, Bits: f.Bits}
+	switch f.FormatTag {
+	case wavFormatPCM:
+	case wavFormatIEEEFloat:
+		p.Float = true
+	default:
+		return p, fmt.Errorf("unsupported wav encoding %s, only PCM and IEEE float are supported", wavFormatName(f.FormatTag))
+	}
+	if err := p.validate(); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// PCMConverter 流式音频格式转换器，读出的是16位小端PCM
+// 依次完成采样位深转换、声道混合和带抗混叠滤波的采样率转换
+type PCMConverter struct {
+	r         io.Reader
+	in        PCMFormat
+	channels  int
+	resampler *Resampler
+
+	buf     []byte
+	pending []byte // 未凑满一帧的输入
+	out     []byte // 已转换尚未读出的输出
+	err     error
+}
+
+// NewPCMConverter 创建从in格式转换到outRate采样率、outChannels声道16位PCM的转换器
+func NewPCMConverter(r io.Reader, in PCMFormat, outRate, outChannels int) (*PCMConverter, error) {
+	if err := in.validate(); err != nil {
+		return nil, err
+	}
+	if outRate <= 0 || outChannels <= 0 {
+		return nil, errors.New("invalid output format")
+	}
+	return &PCMConverter{
+		r:         r,
+		in:        in,
+		channels:  outChannels,
+		resampler: NewResampler(in.SampleRate, outRate, outChannels),
+		buf:       make([]byte, 4096*in.frameSize()),
+	}, nil
+}
+
+// Read 实现io.Reader接口
+func (c *PCMConverter) Read(p []byte) (int, error) {
+	for len(c.out) == 0 && c.err == nil {
+		n, err := c.r.Read(c.buf)
+		if n > 0 {
+			c.convert(c.buf[:n])
+		}
+		if err == io.EOF {
+			c.emit(c.resampler.Flush())
+			c.err = io.EOF
+		} else if err != nil {
+			c.err = fmt.Errorf("failed to read audio: %v", err)
+		}
+	}
+	if len(c.out) > 0 {
+		n := copy(p, c.out)
+		c.out = c.out[n:]
+		return n, nil
+	}
+	return 0, c.err
+}
+
+// convert 转换一段输入，分片边界不必对齐到采样点
+func (c *PCMConverter) convert(p []byte) {
+	data := append(c.pending, p...)
+	frameSize := c.in.frameSize()
+	usable := len(data) - len(data)%frameSize
+
+	sampleSize := c.in.Bits / 8
+	a := &PCMAudio{SampleRate: c.in.SampleRate, Channels: c.in.Channels, Samples: make([]int16, usable/sampleSize)}
+	for i := range a.Samples {
+		a.Samples[i] = c.in.decodeSample(data[i*sampleSize:])
+	}
+	c.pending = append([]byte(nil), data[usable:]...)
+	c.emit(c.resampler.Process(remixChannels(a, c.channels).Samples))
+}
+
+func (c *PCMConverter) emit(samples []int16) {
+	for _, s := range samples {
+		c.out = binary.LittleEndian.AppendUint16(c.out, uint16(s))
+	}
+}
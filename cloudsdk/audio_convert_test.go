@@ -0,0 +1,115 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeSamples 将[-1,1]范围的采样按指定格式编码
+func encodeSamples(samples []float64, f PCMFormat) []byte {
+	var out []byte
+	for _, v := range samples {
+		switch {
+		case f.Float && f.Bits == 32:
+			out = binary.LittleEndian.AppendUint32(out, math.Float32bits(float32(v)))
+		case f.Float:
+			out = binary.LittleEndian.AppendUint64(out, math.Float64bits(v))
+		case f.Bits == 8:
+			out = append(out, byte(int(v*127)+128))
+		case f.Bits == 16:
+			out = binary.LittleEndian.AppendUint16(out, uint16(int16(v*32767)))
+		case f.Bits == 24:
+			s := int32(v * 8388607)
+			out = append(out, byte(s), byte(s>>8), byte(s>>16))
+		case f.Bits == 32:
+			out = binary.LittleEndian.AppendUint32(out, uint32(int32(v*2147483647)))
+		}
+	}
+	return out
+}
+
+// stereoSine 生成左右声道相同的立体声正弦波
+func stereoSine(rate, frames int, freq, amplitude float64) []float64 {
+	var out []float64
+	for _, v := range monoSine(rate, frames, freq, amplitude) {
+		out = append(out, v, v)
+	}
+	return out
+}
+
+func monoSine(rate, frames int, freq, amplitude float64) []float64 {
+	out := make([]float64, frames)
+	for i := range out {
+		out[i] = amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate))
+	}
+	return out
+}
+
+func convertAll(t *testing.T, data []byte, in PCMFormat) *PCMAudio {
+	c, err := NewPCMConverter(bytes.NewReader(data), in, 16000, 1)
+	require.NoError(t, err)
+	out, err := io.ReadAll(c)
+	require.NoError(t, err)
+	return DecodePCM16(out, 16000, 1)
+}
+
+func TestPCMConverter_Formats(t *testing.T) {
+	formats := []PCMFormat{
+		{SampleRate: 48000, Channels: 2, Bits: 8},
+		{SampleRate: 48000, Channels: 2, Bits: 16},
+		{SampleRate: 44100, Channels: 2, Bits: 24},
+		{SampleRate: 44100, Channels: 2, Bits: 32},
+		{SampleRate: 48000, Channels: 2, Bits: 32, Float: true},
+		{SampleRate: 16000, Channels: 2, Bits: 64, Float: true},
+	}
+	for _, f := range formats {
+		data := encodeSamples(stereoSine(f.SampleRate, f.SampleRate, 440, 0.5), f)
+		a := convertAll(t, data, f)
+
+		assert.InDelta(t, 16000, a.Frames(), 2, "%+v", f)
+		// 去掉滤波器边缘后，0.5幅度正弦波的RMS约为-9dBFS
+		assert.InDelta(t, -9, frameEnergyDB(a.Samples[1000:15000]), 0.5, "%+v", f)
+	}
+}
+
+func TestPCMConverter_AntiAliasing(t *testing.T) {
+	// 12kHz超过16kHz输出的奈奎斯特频率，必须被滤除而不是混叠到4kHz
+	f := PCMFormat{SampleRate: 48000, Channels: 1, Bits: 16}
+	data := encodeSamples(monoSine(48000, 24000, 12000, 0.5), f)
+	a := convertAll(t, data, f)
+	assert.Less(t, frameEnergyDB(a.Samples[1000:7000]), -60.0)
+}
+
+func TestPCMConverter_Invalid(t *testing.T) {
+	_, err := NewPCMConverter(bytes.NewReader(nil), PCMFormat{SampleRate: 16000, Channels: 1, Bits: 12}, 16000, 1)
+	assert.ErrorContains(t, err, "12-bit")
+
+	_, err = (&WAVFormat{FormatTag: wavFormatALaw, SampleRate: 8000, Channels: 1, Bits: 8}).PCMFormat()
+	assert.ErrorContains(t, err, "A-law")
+}
+
+func TestAsrWsClient_ConvertInput(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "pcm")
+	client.config.Rate = 44100
+	client.config.Channel = 2
+	client.config.Bits = 32
+	client.config.Float = true
+
+	in := PCMFormat{SampleRate: 44100, Channels: 2, Bits: 32, Float: true}
+	_, err := client.RecognizeReader(context.Background(), bytes.NewReader(encodeSamples(stereoSine(44100, 44100, 440, 0.5), in)))
+	require.NoError(t, err)
+
+	audio := server.request["audio"].(map[string]interface{})
+	assert.Equal(t, float64(16000), audio["sample_rate"])
+	assert.Equal(t, float64(1), audio["channel"])
+	assert.Equal(t, float64(16), audio["bits"])
+	assert.InDelta(t, 32000, len(server.Audio()), 4)
+}
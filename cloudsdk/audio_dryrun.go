@@ -0,0 +1,180 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+	"unicode"
+)
+
+// DryRunSynthesizer 离线合成器，不访问网络
+// 按文本长度估算时长，生成确定性的静音或正弦音占位音频以及伪造的字级别时间戳
+type DryRunSynthesizer struct {
+	Tone           bool          // 每个字对应一段正弦音，否则全部为静音；mp3编码始终为静音
+	CharsPerSecond float64       // 语速，默认每秒4.5个字
+	PauseDuration  time.Duration // 标点处的停顿，默认250ms
+	Encoding       string        // 请求未指定编码时使用，默认"mp3"
+	SampleRate     int           // 请求未指定采样率时使用，默认24000
+	ChunkSize      int           // 流式输出时每次写入的字节数，默认4096
+}
+
+func (d *DryRunSynthesizer) config(req *SynthRequest) (encoding string, rate int, cps float64, pause time.Duration) {
+	encoding, rate, cps, pause = d.Encoding, d.SampleRate, d.CharsPerSecond, d.PauseDuration
+	if req.Encoding != "" {
+		encoding = req.Encoding
+	}
+	if encoding == "" {
+		encoding = "mp3"
+	}
+	if req.SampleRate > 0 {
+		rate = req.SampleRate
+	}
+	if rate <= 0 {
+		rate = defaultTTSSampleRate
+	}
+	if cps <= 0 {
+		cps = 4.5
+	}
+	if req.SpeedRatio > 0 {
+		cps *= float64(req.SpeedRatio)
+	}
+	if pause <= 0 {
+		pause = 250 * time.Millisecond
+	}
+	return
+}
+
+// estimateTimings 按字数和标点估算每个字的时间戳，返回时间戳和总时长
+// 汉字按单字计时，连续的字母数字视为一个词，约每3个字符一个音节
+func estimateTimings(text string, cps float64, pause time.Duration) ([]WordTiming, time.Duration) {
+	unit := time.Duration(float64(time.Second) / cps)
+	var words []WordTiming
+	var cursor time.Duration
+	var latin []rune
+
+	flush := func() {
+		if len(latin) == 0 {
+			return
+		}
+		d := time.Duration(max(1, len(latin)/3)) * unit
+		words = append(words, WordTiming{Word: string(latin), Start: cursor, End: cursor + d})
+		cursor += d
+		latin = latin[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) && r < unicode.MaxLatin1, unicode.IsDigit(r):
+			latin = append(latin, r)
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			flush()
+			cursor += pause
+		default:
+			flush()
+			words = append(words, WordTiming{Word: string(r), Start: cursor, End: cursor + unit})
+			cursor += unit
+		}
+	}
+	flush()
+	return words, cursor
+}
+
+// renderPCM 生成占位PCM，Tone为true时在每个字的时间段内填充正弦音
+func (d *DryRunSynthesizer) renderPCM(words []WordTiming, total time.Duration, rate int) *PCMAudio {
+	frames := int(total.Seconds() * float64(rate))
+	a := &PCMAudio{SampleRate: rate, Channels: 1, Samples: make([]int16, frames)}
+	if !d.Tone {
+		return a
+	}
+	for _, w := range words {
+		start := int(w.Start.Seconds() * float64(rate))
+		end := min(int(w.End.Seconds()*float64(rate)), frames)
+		for i := start; i < end; i++ {
+			a.Samples[i] = int16(3000 * math.Sin(2*math.Pi*440*float64(i)/float64(rate)))
+		}
+	}
+	return a
+}
+
+// silentMP3 生成指定时长的32kbps单声道静音MP3
+func silentMP3(rate int, d time.Duration) ([]byte, error) {
+	for version, rates := range mp3SampleRateTable {
+		for idx, r := range rates {
+			if r != rate {
+				continue
+			}
+			bitrateIndex := byte(4) // MPEG2/2.5 Layer III 32kbps
+			if version == mpegVersion1 {
+				bitrateIndex = 1 // MPEG1 Layer III 32kbps
+			}
+			header := []byte{0xff, 0xe0 | byte(version)<<3 | 0x01<<1 | 0x01, bitrateIndex<<4 | byte(idx)<<2, 0xc0}
+			h, _ := parseMP3FrameHeader(header)
+
+			frame := make([]byte, h.Size)
+			copy(frame, header)
+			n := int(math.Ceil(d.Seconds() * float64(rate) / float64(h.Samples)))
+			return bytes.Repeat(frame, n), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported mp3 sample rate: %d", rate)
+}
+
+// Synthesize 实现Synthesizer接口
+func (d *DryRunSynthesizer) Synthesize(ctx context.Context, req *SynthRequest) (*SynthResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	encoding, rate, cps, pause := d.config(req)
+	words, total := estimateTimings(req.Text, cps, pause)
+	pcm := d.renderPCM(words, total, rate)
+
+	result := &SynthResult{Encoding: encoding, SampleRate: rate, Duration: total, Words: words}
+	switch encoding {
+	case "pcm":
+		result.Audio = pcm.Bytes()
+	case "wav":
+		result.Audio = pcm.WAV()
+	case "mp3":
+		audio, err := silentMP3(rate, total)
+		if err != nil {
+			return nil, err
+		}
+		result.Audio = audio
+	default:
+		return nil, invalidRequest(fmt.Errorf("unsupported encoding: %s", encoding))
+	}
+	return result, nil
+}
+
+// SynthesizeStream 实现Synthesizer接口，将占位音频分块写入w
+func (d *DryRunSynthesizer) SynthesizeStream(ctx context.Context, req *SynthRequest, w io.Writer) error {
+	result, err := d.Synthesize(ctx, req)
+	if err != nil {
+		return err
+	}
+	chunk := d.ChunkSize
+	if chunk <= 0 {
+		chunk = 4096
+	}
+	for audio := result.Audio; len(audio) > 0; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := min(chunk, len(audio))
+		if _, err := w.Write(audio[:n]); err != nil {
+			return fmt.Errorf("write audio failed: %v", err)
+		}
+		audio = audio[n:]
+	}
+	return nil
+}
+
+// SynthesizeBatch 实现Synthesizer接口
+func (d *DryRunSynthesizer) SynthesizeBatch(ctx context.Context, reqs []*SynthRequest) ([]*SynthResult, error) {
+	return synthesizeBatch(ctx, d, reqs)
+}
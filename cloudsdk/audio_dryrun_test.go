@@ -0,0 +1,58 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunSynthesizer_Timings(t *testing.T) {
+	synth := NewSynthesizer(SynthConfig{DryRun: true})
+	result, err := synth.Synthesize(context.Background(), &SynthRequest{Text: "你好，world!", Encoding: "pcm", SampleRate: 16000})
+	require.NoError(t, err)
+
+	cps := 4.5
+	unit := time.Duration(float64(time.Second) / cps)
+	require.Len(t, result.Words, 3)
+	assert.Equal(t, "你", result.Words[0].Word)
+	assert.Equal(t, "world", result.Words[2].Word)
+	assert.Equal(t, 2*unit+250*time.Millisecond, result.Words[2].Start)
+	assert.Equal(t, 3*unit+500*time.Millisecond, result.Duration)
+
+	pcm := DecodePCM16(result.Audio, 16000, 1)
+	assert.InDelta(t, result.Duration.Seconds(), pcm.Duration().Seconds(), 0.001)
+	assert.Equal(t, 0.0, AnalyzeAudioQuality(pcm).Peak)
+}
+
+func TestDryRunSynthesizer_Encodings(t *testing.T) {
+	synth := &DryRunSynthesizer{Tone: true}
+	req := &SynthRequest{Text: "益母草生长在深山老林里"}
+
+	mp3, err := synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "mp3", mp3.Encoding)
+	d, err := mp3Duration(mp3.Audio)
+	require.NoError(t, err)
+	assert.InDelta(t, mp3.Duration.Seconds(), d.Seconds(), 0.05)
+
+	req.Encoding = "wav"
+	wav, err := synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	audio, err := ReadWAV(wav.Audio)
+	require.NoError(t, err)
+	assert.Equal(t, defaultTTSSampleRate, audio.SampleRate)
+	assert.Greater(t, AnalyzeAudioQuality(audio).Peak, 0.05)
+
+	// 相同输入应得到完全相同的输出
+	again, err := synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, wav.Audio, again.Audio)
+
+	var streamed bytes.Buffer
+	require.NoError(t, synth.SynthesizeStream(context.Background(), req, &streamed))
+	assert.Equal(t, wav.Audio, streamed.Bytes())
+}
@@ -0,0 +1,242 @@
+package cloudsdk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// G711Law G.711 压扩律
+type G711Law int
+
+const (
+	MuLaw G711Law = iota // G.711 μ-law (PCMU)
+	ALaw                 // G.711 A-law (PCMA)
+)
+
+// G711SampleRate G.711 固定使用8kHz单声道
+const G711SampleRate = 8000
+
+const (
+	muLawBias = 0x84
+	muLawClip = 32635
+)
+
+var aLawSegEnd = [8]int{0x1f, 0x3f, 0x7f, 0xff, 0x1ff, 0x3ff, 0x7ff, 0xfff}
+
+// linearToMuLaw 将16位线性PCM编码为μ-law
+func linearToMuLaw(s int16) byte {
+	v := int(s)
+	sign := 0
+	if v < 0 {
+		v = -v
+		sign = 0x80
+	}
+	if v > muLawClip {
+		v = muLawClip
+	}
+	v += muLawBias
+
+	exponent := 7
+	for mask := 0x4000; v&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (v >> (exponent + 3)) & 0x0f
+	return ^byte(sign | exponent<<4 | mantissa)
+}
+
+// muLawToLinear 将μ-law解码为16位线性PCM
+func muLawToLinear(u byte) int16 {
+	u = ^u
+	exponent := int(u>>4) & 0x07
+	mantissa := int(u) & 0x0f
+	v := ((mantissa << 3) + muLawBias) << exponent
+	v -= muLawBias
+	if u&0x80 != 0 {
+		return int16(-v)
+	}
+	return int16(v)
+}
+
+// linearToALaw 将16位线性PCM编码为A-law
+func linearToALaw(s int16) byte {
+	v := int(s) >> 3
+	mask := 0xd5
+	if v < 0 {
+		mask = 0x55
+		v = -v - 1
+	}
+
+	seg := 0
+	for seg < 8 && v > aLawSegEnd[seg] {
+		seg++
+	}
+	if seg >= 8 {
+		return byte(0x7f ^ mask)
+	}
+
+	aval := seg << 4
+	if seg < 2 {
+		aval |= (v >> 1) & 0x0f
+	} else {
+		aval |= (v >> seg) & 0x0f
+	}
+	return byte(aval ^ mask)
+}
+
+// aLawToLinear 将A-law解码为16位线性PCM
+func aLawToLinear(a byte) int16 {
+	a ^= 0x55
+	t := int(a&0x0f) << 4
+	seg := int(a&0x70) >> 4
+	switch seg {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= seg - 1
+	}
+	if a&0x80 != 0 {
+		return int16(t)
+	}
+	return int16(-t)
+}
+
+// encodeG711Samples 按压扩律逐点编码
+func encodeG711Samples(samples []int16, law G711Law) []byte {
+	out := make([]byte, len(samples))
+	for i, s := range samples {
+		if law == ALaw {
+			out[i] = linearToALaw(s)
+		} else {
+			out[i] = linearToMuLaw(s)
+		}
+	}
+	return out
+}
+
+// EncodeG711 将任意采样率和声道数的PCM音频转换为8kHz单声道G.711数据
+func EncodeG711(a *PCMAudio, law G711Law) []byte {
+	mono := Resample(remixChannels(a, 1), G711SampleRate)
+	return encodeG711Samples(mono.Samples, law)
+}
+
+// DecodeG711 将G.711数据解码为8kHz单声道16位PCM
+func DecodeG711(data []byte, law G711Law) *PCMAudio {
+	a := &PCMAudio{SampleRate: G711SampleRate, Channels: 1, Samples: make([]int16, len(data))}
+	for i, b := range data {
+		if law == ALaw {
+			a.Samples[i] = aLawToLinear(b)
+		} else {
+			a.Samples[i] = muLawToLinear(b)
+		}
+	}
+	return a
+}
+
+// G711Encoder 流式G.711编码器
+// 写入16位小端PCM分片，边重采样边将G.711数据写到下游
+type G711Encoder struct {
+	w         io.Writer
+	law       G711Law
+	channels  int
+	resampler *Resampler
+	pending   []byte // 未凑满一帧的剩余字节
+}
+
+// NewG711Encoder 创建流式编码器，srcRate/srcChannels为输入PCM的格式，不大于0时按8kHz单声道处理
+func NewG711Encoder(w io.Writer, law G711Law, srcRate, srcChannels int) *G711Encoder {
+	if srcRate <= 0 {
+		srcRate = G711SampleRate
+	}
+	if srcChannels <= 0 {
+		srcChannels = 1
+	}
+	return &G711Encoder{
+		w:         w,
+		law:       law,
+		channels:  srcChannels,
+		resampler: NewResampler(srcRate, G711SampleRate, 1),
+	}
+}
+
+// Write 写入一段PCM数据，分片边界不必对齐到采样点
+// p总会被全部消费，下游写入失败时仍返回len(p)和错误，调用方不应重写这部分数据
+func (e *G711Encoder) Write(p []byte) (int, error) {
+	data := append(e.pending, p...)
+	frameSize := 2 * e.channels
+	usable := len(data) - len(data)%frameSize
+	e.pending = append([]byte(nil), data[usable:]...)
+
+	frames := usable / frameSize
+	mono := make([]int16, frames)
+	for i := 0; i < frames; i++ {
+		var sum int
+		for c := 0; c < e.channels; c++ {
+			sum += int(int16(binary.LittleEndian.Uint16(data[(i*e.channels+c)*2:])))
+		}
+		mono[i] = int16(sum / e.channels)
+	}
+
+	if out := e.resampler.Process(mono); len(out) > 0 {
+		if _, err := e.w.Write(encodeG711Samples(out, e.law)); err != nil {
+			return len(p), fmt.Errorf("write g711 data failed: %v", err)
+		}
+	}
+	return len(p), nil
+}
+
+// Close 输出重采样器中剩余的数据，不会关闭下游Writer
+func (e *G711Encoder) Close() error {
+	if out := e.resampler.Flush(); len(out) > 0 {
+		if _, err := e.w.Write(encodeG711Samples(out, e.law)); err != nil {
+			return fmt.Errorf("write g711 data failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// G711Decoder 流式G.711解码器，读出8kHz单声道16位小端PCM
+type G711Decoder struct {
+	r   io.Reader
+	law G711Law
+	buf []byte
+}
+
+// NewG711Decoder 创建从r读取G.711数据的解码器
+func NewG711Decoder(r io.Reader, law G711Law) *G711Decoder {
+	return &G711Decoder{r: r, law: law}
+}
+
+// Read 实现io.Reader接口，每个G.711字节解码为2字节PCM
+func (d *G711Decoder) Read(p []byte) (int, error) {
+	n := len(p) / 2
+	if n == 0 {
+		return 0, io.ErrShortBuffer
+	}
+	if cap(d.buf) < n {
+		d.buf = make([]byte, n)
+	}
+	n, err := d.r.Read(d.buf[:n])
+	for i, b := range d.buf[:n] {
+		s := muLawToLinear(b)
+		if d.law == ALaw {
+			s = aLawToLinear(b)
+		}
+		binary.LittleEndian.PutUint16(p[2*i:], uint16(s))
+	}
+	return 2 * n, err
+}
+
+// g711Law 按音频格式名返回压扩律，pcmu/pcma与RTP中的编码名一致
+func g711Law(format string) (G711Law, bool) {
+	switch format {
+	case "pcmu":
+		return MuLaw, true
+	case "pcma":
+		return ALaw, true
+	}
+	return 0, false
+}
@@ -0,0 +1,121 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snrDB 计算信号相对误差的信噪比，跳过两端滤波器暂态
+func snrDB(ref, got []int16, skip int) float64 {
+	n := min(len(ref), len(got))
+	var sig, noise float64
+	for i := skip; i < n-skip; i++ {
+		d := float64(ref[i]) - float64(got[i])
+		sig += float64(ref[i]) * float64(ref[i])
+		noise += d * d
+	}
+	if noise == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(sig/noise)
+}
+
+func TestG711_KnownValues(t *testing.T) {
+	assert.Equal(t, byte(0xff), linearToMuLaw(0))
+	assert.Equal(t, byte(0xd5), linearToALaw(0))
+	assert.Equal(t, byte(0x80), linearToMuLaw(math.MaxInt16))
+	assert.Equal(t, byte(0xaa), linearToALaw(math.MaxInt16))
+
+	// 所有码字解码再编码应保持不变
+	for i := 0; i < 256; i++ {
+		if i != 0x7f { // μ-law 的负零
+			assert.Equal(t, byte(i), linearToMuLaw(muLawToLinear(byte(i))), "mu-law code %#x", i)
+		}
+		assert.Equal(t, byte(i), linearToALaw(aLawToLinear(byte(i))), "a-law code %#x", i)
+	}
+}
+
+func TestG711_RoundTrip(t *testing.T) {
+	src := sineTone(24000, 24000, 440, 12000)
+	ref := Resample(src, G711SampleRate)
+	require.Equal(t, 8000, ref.Frames())
+
+	for _, law := range []G711Law{MuLaw, ALaw} {
+		encoded := EncodeG711(src, law)
+		require.Len(t, encoded, ref.Frames())
+
+		decoded := DecodeG711(encoded, law)
+		assert.Greater(t, snrDB(ref.Samples, decoded.Samples, 100), 30.0)
+		assert.Greater(t, snrDB(sineTone(8000, 8000, 440, 12000).Samples, decoded.Samples, 100), 25.0)
+	}
+}
+
+func TestG711Encoder_Streaming(t *testing.T) {
+	src := sineTone(24000, 24000, 440, 12000)
+	pcm := src.Bytes()
+	want := EncodeG711(src, ALaw)
+
+	var out bytes.Buffer
+	enc := NewG711Encoder(&out, ALaw, 24000, 1)
+	// 奇数长度的分片，模拟TTS按任意边界返回
+	for len(pcm) > 0 {
+		n := min(777, len(pcm))
+		_, err := enc.Write(pcm[:n])
+		require.NoError(t, err)
+		pcm = pcm[n:]
+	}
+	require.NoError(t, enc.Close())
+	assert.Equal(t, want, out.Bytes())
+}
+
+// failingWriter 总是写入失败
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestG711Encoder_Errors(t *testing.T) {
+	// 声道数未设置时按单声道处理
+	var out bytes.Buffer
+	enc := NewG711Encoder(&out, MuLaw, 8000, 0)
+	src := sineTone(8000, 800, 440, 12000)
+	n, err := enc.Write(src.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, len(src.Bytes()), n)
+
+	// 下游出错时仍报告已消费的字节数
+	enc = NewG711Encoder(failingWriter{}, MuLaw, 8000, 1)
+	n, err = enc.Write(src.Bytes())
+	assert.ErrorContains(t, err, "broken pipe")
+	assert.Equal(t, len(src.Bytes()), n)
+}
+
+func TestResample_Upsample(t *testing.T) {
+	src := sineTone(8000, 8000, 300, 10000)
+	up := Resample(src, 16000)
+	assert.Equal(t, 16000, up.Frames())
+	assert.Greater(t, snrDB(sineTone(16000, 16000, 300, 10000).Samples, up.Samples, 200), 40.0)
+}
+
+func TestResample_AntiAliasing(t *testing.T) {
+	// 6kHz 超出8kHz的奈奎斯特频率，应被滤除
+	src := sineTone(48000, 48000, 6000, 16000)
+	down := Resample(src, G711SampleRate)
+	assert.Less(t, frameEnergyDB(down.Samples[200:len(down.Samples)-200]), -50.0)
+}
+
+func TestG711Decoder(t *testing.T) {
+	encoded := EncodeG711(sineTone(8000, 800, 440, 12000), MuLaw)
+	want := DecodeG711(encoded, MuLaw).Bytes()
+
+	// 逐字节读取，验证分片边界
+	got, err := io.ReadAll(NewG711Decoder(iotest.OneByteReader(bytes.NewReader(encoded)), MuLaw))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
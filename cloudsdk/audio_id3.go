@@ -0,0 +1,228 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ID3Picture 封面图片
+type ID3Picture struct {
+	MIMEType    string // 例如 "image/jpeg"
+	Description string
+	Data        []byte
+}
+
+// ID3Chapter 章节信息，对应一个CHAP帧
+type ID3Chapter struct {
+	ID    string // 章节元素ID，为空时自动生成
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// ID3Tag 描述要写入MP3文件的ID3v2.4标签
+type ID3Tag struct {
+	Title      string
+	Artist     string
+	Album      string
+	Track      int // 0 表示不写入
+	TrackTotal int // 0 表示不写入
+	Cover      *ID3Picture
+	Chapters   []ID3Chapter
+}
+
+// AudioSegment 一段待合并的音频，通常对应一个章节
+type AudioSegment struct {
+	Title string
+	Audio []byte
+}
+
+// encodeSynchsafe 将整数编码为ID3v2使用的synchsafe整数(每字节7位)
+func encodeSynchsafe(n int) []byte {
+	return []byte{
+		byte(n>>21) & 0x7f,
+		byte(n>>14) & 0x7f,
+		byte(n>>7) & 0x7f,
+		byte(n) & 0x7f,
+	}
+}
+
+// decodeSynchsafe 解码4字节的synchsafe整数
+func decodeSynchsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// id3Frame 构造一个ID3v2.4帧
+func id3Frame(id string, body []byte) []byte {
+	frame := make([]byte, 0, 10+len(body))
+	frame = append(frame, id...)
+	frame = append(frame, encodeSynchsafe(len(body))...)
+	frame = append(frame, 0x00, 0x00) // flags
+	return append(frame, body...)
+}
+
+// id3TextFrame 构造UTF-8编码的文本帧
+func id3TextFrame(id, text string) []byte {
+	body := append([]byte{0x03}, text...)
+	return id3Frame(id, body)
+}
+
+// Bytes 将标签序列化为完整的ID3v2.4标签数据
+func (tag *ID3Tag) Bytes() ([]byte, error) {
+	var frames bytes.Buffer
+
+	if tag.Title != "" {
+		frames.Write(id3TextFrame("TIT2", tag.Title))
+	}
+	if tag.Artist != "" {
+		frames.Write(id3TextFrame("TPE1", tag.Artist))
+	}
+	if tag.Album != "" {
+		frames.Write(id3TextFrame("TALB", tag.Album))
+	}
+	if tag.Track > 0 {
+		track := strconv.Itoa(tag.Track)
+		if tag.TrackTotal > 0 {
+			track = fmt.Sprintf("%d/%d", tag.Track, tag.TrackTotal)
+		}
+		frames.Write(id3TextFrame("TRCK", track))
+	}
+
+	if tag.Cover != nil {
+		if len(tag.Cover.Data) == 0 {
+			return nil, errors.New("cover picture is empty")
+		}
+		mime := tag.Cover.MIMEType
+		if mime == "" {
+			mime = "image/jpeg"
+		}
+		var body bytes.Buffer
+		body.WriteByte(0x03) // UTF-8
+		body.WriteString(mime)
+		body.WriteByte(0x00)
+		body.WriteByte(0x03) // front cover
+		body.WriteString(tag.Cover.Description)
+		body.WriteByte(0x00)
+		body.Write(tag.Cover.Data)
+		frames.Write(id3Frame("APIC", body.Bytes()))
+	}
+
+	if len(tag.Chapters) > 0 {
+		chapterFrames, err := id3ChapterFrames(tag.Chapters)
+		if err != nil {
+			return nil, err
+		}
+		frames.Write(chapterFrames)
+	}
+
+	header := []byte{'I', 'D', '3', 0x04, 0x00, 0x00}
+	header = append(header, encodeSynchsafe(frames.Len())...)
+	return append(header, frames.Bytes()...), nil
+}
+
+// id3ChapterFrames 构造CTOC目录帧和各章节的CHAP帧
+func id3ChapterFrames(chapters []ID3Chapter) ([]byte, error) {
+	if len(chapters) > 255 {
+		return nil, fmt.Errorf("too many chapters: %d, at most 255 supported", len(chapters))
+	}
+
+	var toc, chaps bytes.Buffer
+	toc.WriteString("toc")
+	toc.WriteByte(0x00)
+	toc.WriteByte(0x03) // top-level | ordered
+	toc.WriteByte(byte(len(chapters)))
+
+	for i, ch := range chapters {
+		if ch.End < ch.Start {
+			return nil, fmt.Errorf("chapter %d ends before it starts", i)
+		}
+		id := ch.ID
+		if id == "" {
+			id = fmt.Sprintf("chp%d", i)
+		}
+		toc.WriteString(id)
+		toc.WriteByte(0x00)
+
+		var body bytes.Buffer
+		body.WriteString(id)
+		body.WriteByte(0x00)
+		binary.Write(&body, binary.BigEndian, uint32(ch.Start.Milliseconds()))
+		binary.Write(&body, binary.BigEndian, uint32(ch.End.Milliseconds()))
+		binary.Write(&body, binary.BigEndian, uint32(0xffffffff)) // 不使用字节偏移
+		binary.Write(&body, binary.BigEndian, uint32(0xffffffff))
+		if ch.Title != "" {
+			body.Write(id3TextFrame("TIT2", ch.Title))
+		}
+		chaps.Write(id3Frame("CHAP", body.Bytes()))
+	}
+
+	return append(id3Frame("CTOC", toc.Bytes()), chaps.Bytes()...), nil
+}
+
+// ChaptersFromDurations 根据各段时长依次排列出章节的起止时间
+func ChaptersFromDurations(titles []string, durations []time.Duration) []ID3Chapter {
+	chapters := make([]ID3Chapter, 0, len(durations))
+	var offset time.Duration
+	for i, d := range durations {
+		ch := ID3Chapter{
+			ID:    fmt.Sprintf("chp%d", i),
+			Start: offset,
+			End:   offset + d,
+		}
+		if i < len(titles) {
+			ch.Title = titles[i]
+		}
+		chapters = append(chapters, ch)
+		offset += d
+	}
+	return chapters
+}
+
+// MergeMP3WithChapters 合并多段MP3音频并写入带章节的ID3v2.4标签
+// 各段已有的ID3标签会被去掉，章节起止时间由每段的实际时长计算得出，因此tag中不能预先设置章节
+func MergeMP3WithChapters(tag ID3Tag, segments []AudioSegment) ([]byte, error) {
+	if len(segments) == 0 {
+		return nil, errors.New("no audio segments to merge")
+	}
+	if len(tag.Chapters) > 0 {
+		return nil, errors.New("tag chapters are generated from segments and must be empty")
+	}
+
+	titles := make([]string, len(segments))
+	durations := make([]time.Duration, len(segments))
+	var audio bytes.Buffer
+	for i, seg := range segments {
+		data := stripID3(seg.Audio)
+		d, err := mp3Duration(data)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %v", i, err)
+		}
+		titles[i] = seg.Title
+		durations[i] = d
+		audio.Write(data)
+	}
+
+	tag.Chapters = ChaptersFromDurations(titles, durations)
+	header, err := tag.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("build id3 tag failed: %v", err)
+	}
+	return append(header, audio.Bytes()...), nil
+}
+
+// WriteAudiobookMP3 合并各段音频为一个带章节导航的MP3文件
+func WriteAudiobookMP3(outFile string, tag ID3Tag, segments []AudioSegment) error {
+	data, err := MergeMP3WithChapters(tag, segments)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outFile, data, 0644); err != nil {
+		return fmt.Errorf("write output file failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMP3 生成n个 MPEG1 Layer III 128kbps 44.1kHz 的静音帧
+func fakeMP3(n int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+	return bytes.Repeat(frame, n)
+}
+
+// readID3Frames 解析标签中的顶层帧，返回帧ID到帧内容的映射
+func readID3Frames(t *testing.T, data []byte) map[string][][]byte {
+	require.Equal(t, "ID3", string(data[0:3]))
+	require.Equal(t, byte(0x04), data[3])
+	size := decodeSynchsafe(data[6:10])
+	body := data[10 : 10+size]
+
+	frames := map[string][][]byte{}
+	for len(body) >= 10 {
+		id := string(body[0:4])
+		n := decodeSynchsafe(body[4:8])
+		frames[id] = append(frames[id], body[10:10+n])
+		body = body[10+n:]
+	}
+	return frames
+}
+
+func TestMP3Duration(t *testing.T) {
+	d, err := mp3Duration(fakeMP3(100))
+	require.NoError(t, err)
+	// 每帧1152个采样点
+	assert.Equal(t, 100*1152*time.Second/44100, d)
+
+	_, err = mp3Duration([]byte("not an mp3"))
+	assert.Error(t, err)
+}
+
+func TestID3Tag_Bytes(t *testing.T) {
+	tag := ID3Tag{
+		Title:      "第一章",
+		Artist:     "旁白",
+		Album:      "益母草的故事",
+		Track:      1,
+		TrackTotal: 3,
+		Cover:      &ID3Picture{MIMEType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}},
+	}
+	data, err := tag.Bytes()
+	require.NoError(t, err)
+
+	frames := readID3Frames(t, data)
+	assert.Equal(t, "\x03第一章", string(frames["TIT2"][0]))
+	assert.Equal(t, "\x03旁白", string(frames["TPE1"][0]))
+	assert.Equal(t, "\x03益母草的故事", string(frames["TALB"][0]))
+	assert.Equal(t, "\x031/3", string(frames["TRCK"][0]))
+	assert.True(t, bytes.HasPrefix(frames["APIC"][0], []byte("\x03image/png\x00\x03\x00")))
+}
+
+func TestMergeMP3WithChapters(t *testing.T) {
+	segments := []AudioSegment{
+		{Title: "序章", Audio: fakeMP3(10)},
+		{Title: "第一章", Audio: fakeMP3(20)},
+	}
+	data, err := MergeMP3WithChapters(ID3Tag{Album: "测试"}, segments)
+	require.NoError(t, err)
+
+	frames := readID3Frames(t, data)
+	require.Len(t, frames["CTOC"], 1)
+	assert.Equal(t, "toc\x00\x03\x02chp0\x00chp1\x00", string(frames["CTOC"][0]))
+
+	chaps := frames["CHAP"]
+	require.Len(t, chaps, 2)
+	second := chaps[1]
+	assert.Equal(t, "chp1\x00", string(second[:5]))
+	start := binary.BigEndian.Uint32(second[5:9])
+	end := binary.BigEndian.Uint32(second[9:13])
+	assert.Equal(t, uint32((10 * 1152 * time.Second / 44100).Milliseconds()), start)
+	assert.Equal(t, uint32((30 * 1152 * time.Second / 44100).Milliseconds()), end)
+	assert.Contains(t, string(second), "第一章")
+
+	tagSize := id3v2Size(data)
+	assert.Equal(t, 30*417, len(data)-tagSize)
+
+	// 章节由各段生成，调用方预设的章节不会被悄悄覆盖
+	_, err = MergeMP3WithChapters(ID3Tag{Chapters: []ID3Chapter{{Title: "x"}}}, segments)
+	assert.ErrorContains(t, err, "must be empty")
+}
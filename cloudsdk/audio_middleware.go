@@ -0,0 +1,639 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SynthMiddleware 包装Synthesizer的中间件
+type SynthMiddleware func(Synthesizer) Synthesizer
+
+// RecognizerMiddleware 包装Recognizer的中间件
+type RecognizerMiddleware func(Recognizer) Recognizer
+
+// RecognizerFingerprint 可选接口，返回影响识别结果的配置摘要(热词、请求参数、音频格式等)
+// RecognizerCache将其计入缓存键，配置不同的识别器不会共用缓存结果；中间件会转发到被包装的识别器
+type RecognizerFingerprint interface {
+	Fingerprint() string
+}
+
+// fingerprint 返回r的配置摘要，r未实现RecognizerFingerprint时返回空串
+func fingerprint(r Recognizer) string {
+	if f, ok := r.(RecognizerFingerprint); ok {
+		return f.Fingerprint()
+	}
+	return ""
+}
+
+// SynthesizerFingerprint 可选接口，返回请求字段为零值时所用默认配置的摘要(音色、集群、编码、采样率等)
+// SynthCache将其计入缓存键，默认配置不同的合成器不会共用缓存的音频；中间件会转发到被包装的合成器
+type SynthesizerFingerprint interface {
+	Fingerprint() string
+}
+
+// synthFingerprint 返回s的配置摘要，s未实现SynthesizerFingerprint时返回空串
+func synthFingerprint(s Synthesizer) string {
+	if f, ok := s.(SynthesizerFingerprint); ok {
+		return f.Fingerprint()
+	}
+	return ""
+}
+
+// ChainSynthesizer 按顺序套上中间件，第一个中间件位于最外层
+func ChainSynthesizer(s Synthesizer, mws ...SynthMiddleware) Synthesizer {
+	for i := len(mws) - 1; i >= 0; i-- {
+		s = mws[i](s)
+	}
+	return s
+}
+
+// ChainRecognizer 按顺序套上中间件，第一个中间件位于最外层
+func ChainRecognizer(r Recognizer, mws ...RecognizerMiddleware) Recognizer {
+	for i := len(mws) - 1; i >= 0; i-- {
+		r = mws[i](r)
+	}
+	return r
+}
+
+// ---------- 日志 ----------
+
+type loggingSynth struct {
+	next   Synthesizer
+	logger *log.Logger
+}
+
+func (s *loggingSynth) Fingerprint() string { return synthFingerprint(s.next) }
+
+// SynthLogging 记录每次合成的文本长度、耗时和错误，logger为空时使用标准日志
+func SynthLogging(logger *log.Logger) SynthMiddleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next Synthesizer) Synthesizer {
+		return &loggingSynth{next: next, logger: logger}
+	}
+}
+
+func (s *loggingSynth) Synthesize(ctx context.Context, req *SynthRequest) (*SynthResult, error) {
+	start := time.Now()
+	result, err := s.next.Synthesize(ctx, req)
+	if err != nil {
+		s.logger.Printf("synthesize %d chars failed after %v: %v", len([]rune(req.Text)), time.Since(start), err)
+		return nil, err
+	}
+	s.logger.Printf("synthesize %d chars -> %d bytes %s in %v", len([]rune(req.Text)), len(result.Audio), result.Encoding, time.Since(start))
+	return result, nil
+}
+
+func (s *loggingSynth) SynthesizeStream(ctx context.Context, req *SynthRequest, w io.Writer) error {
+	start := time.Now()
+	cw := &countingWriter{w: w}
+	err := s.next.SynthesizeStream(ctx, req, cw)
+	if err != nil {
+		s.logger.Printf("stream synthesize %d chars failed after %v (%d bytes written): %v", len([]rune(req.Text)), time.Since(start), cw.n, err)
+		return err
+	}
+	s.logger.Printf("stream synthesize %d chars -> %d bytes in %v", len([]rune(req.Text)), cw.n, time.Since(start))
+	return nil
+}
+
+func (s *loggingSynth) SynthesizeBatch(ctx context.Context, reqs []*SynthRequest) ([]*SynthResult, error) {
+	return synthesizeBatch(ctx, s, reqs)
+}
+
+type loggingRecognizer struct {
+	next   Recognizer
+	logger *log.Logger
+}
+
+func (r *loggingRecognizer) Fingerprint() string { return fingerprint(r.next) }
+
+// RecognizerLogging 记录每次识别的耗时、结果长度和错误，logger为空时使用标准日志
+func RecognizerLogging(logger *log.Logger) RecognizerMiddleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next Recognizer) Recognizer {
+		return &loggingRecognizer{next: next, logger: logger}
+	}
+}
+
+func (r *loggingRecognizer) log(start time.Time, req *RecognizeRequest, result *RecognizeResult, err error) {
+	source := req.AudioPath
+	if source == "" {
+		source = "<reader>"
+	}
+	if err != nil {
+		r.logger.Printf("recognize %s failed after %v: %v", source, time.Since(start), err)
+		return
+	}
+	r.logger.Printf("recognize %s -> %d chars in %v", source, len([]rune(result.Text)), time.Since(start))
+}
+
+func (r *loggingRecognizer) Recognize(ctx context.Context, req *RecognizeRequest) (*RecognizeResult, error) {
+	start := time.Now()
+	result, err := r.next.Recognize(ctx, req)
+	r.log(start, req, result, err)
+	return result, err
+}
+
+func (r *loggingRecognizer) RecognizeStreaming(ctx context.Context, req *RecognizeRequest, onResult func(*RecognizeResult)) (*RecognizeResult, error) {
+	start := time.Now()
+	result, err := r.next.RecognizeStreaming(ctx, req, onResult)
+	r.log(start, req, result, err)
+	return result, err
+}
+
+func (r *loggingRecognizer) RecognizeBatch(ctx context.Context, reqs []*RecognizeRequest) ([]*RecognizeResult, error) {
+	return recognizeBatch(ctx, r, reqs)
+}
+
+// ---------- 缓存 ----------
+
+// Cache 中间件使用的键值缓存
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+}
+
+// MemoryCache 进程内LRU缓存
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCache 创建最多保存maxEntries条记录的LRU缓存
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{maxEntries: maxEntries, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*cacheEntry).value, true
+	}
+	return nil, false
+}
+
+func (c *MemoryCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*cacheEntry).value = value
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, value: value})
+	if c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey 以请求的JSON序列化结果计算缓存键
+func cacheKey(prefix string, v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return prefix + hex.EncodeToString(sum[:])
+}
+
+type cachingSynth struct {
+	next  Synthesizer
+	cache Cache
+}
+
+func (s *cachingSynth) Fingerprint() string { return synthFingerprint(s.next) }
+
+// SynthCache 缓存相同请求的合成结果
+func SynthCache(cache Cache) SynthMiddleware {
+	return func(next Synthesizer) Synthesizer {
+		return &cachingSynth{next: next, cache: cache}
+	}
+}
+
+// key 以请求和合成器的默认配置计算缓存键
+func (s *cachingSynth) key(prefix string, req *SynthRequest) string {
+	return cacheKey(prefix, struct {
+		Request     *SynthRequest
+		Synthesizer string
+	}{req, synthFingerprint(s.next)})
+}
+
+func (s *cachingSynth) Synthesize(ctx context.Context, req *SynthRequest) (*SynthResult, error) {
+	key := s.key("synth:", req)
+	if data, ok := s.cache.Get(key); ok {
+		var result SynthResult
+		if err := json.Unmarshal(data, &result); err == nil {
+			return &result, nil
+		}
+	}
+
+	result, err := s.next.Synthesize(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(result); err == nil {
+		s.cache.Set(key, data)
+	}
+	return result, nil
+}
+
+func (s *cachingSynth) SynthesizeStream(ctx context.Context, req *SynthRequest, w io.Writer) error {
+	key := s.key("synth-stream:", req)
+	if audio, ok := s.cache.Get(key); ok {
+		if _, err := w.Write(audio); err != nil {
+			return fmt.Errorf("write audio failed: %v", err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := s.next.SynthesizeStream(ctx, req, io.MultiWriter(w, &buf)); err != nil {
+		return err
+	}
+	s.cache.Set(key, buf.Bytes())
+	return nil
+}
+
+func (s *cachingSynth) SynthesizeBatch(ctx context.Context, reqs []*SynthRequest) ([]*SynthResult, error) {
+	return synthesizeBatch(ctx, s, reqs)
+}
+
+type cachingRecognizer struct {
+	next  Recognizer
+	cache Cache
+}
+
+func (r *cachingRecognizer) Fingerprint() string { return fingerprint(r.next) }
+
+// RecognizerCache 缓存相同音频文件的识别结果，只对AudioPath形式的请求生效
+func RecognizerCache(cache Cache) RecognizerMiddleware {
+	return func(next Recognizer) Recognizer {
+		return &cachingRecognizer{next: next, cache: cache}
+	}
+}
+
+// key 以文件内容、格式参数和识别器配置计算缓存键，无法计算时返回空串
+func (r *cachingRecognizer) key(req *RecognizeRequest) string {
+	if req.Audio != nil || req.AudioPath == "" {
+		return ""
+	}
+	f, err := os.Open(req.AudioPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return cacheKey("asr:", struct {
+		Audio                      string
+		Format                     string
+		SampleRate, Bits, Channels int
+		Recognizer                 string
+	}{hex.EncodeToString(h.Sum(nil)), req.Format, req.SampleRate, req.Bits, req.Channels, fingerprint(r.next)})
+}
+
+func (r *cachingRecognizer) lookup(key string) (*RecognizeResult, bool) {
+	if key == "" {
+		return nil, false
+	}
+	data, ok := r.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	var result RecognizeResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, false
+	}
+	return &result, true
+}
+
+func (r *cachingRecognizer) store(key string, result *RecognizeResult) {
+	if key == "" {
+		return
+	}
+	if data, err := json.Marshal(result); err == nil {
+		r.cache.Set(key, data)
+	}
+}
+
+func (r *cachingRecognizer) Recognize(ctx context.Context, req *RecognizeRequest) (*RecognizeResult, error) {
+	return r.RecognizeStreaming(ctx, req, nil)
+}
+
+func (r *cachingRecognizer) RecognizeStreaming(ctx context.Context, req *RecognizeRequest, onResult func(*RecognizeResult)) (*RecognizeResult, error) {
+	key := r.key(req)
+	if result, ok := r.lookup(key); ok {
+		if onResult != nil {
+			onResult(result)
+		}
+		return result, nil
+	}
+
+	var result *RecognizeResult
+	var err error
+	if onResult != nil {
+		result, err = r.next.RecognizeStreaming(ctx, req, onResult)
+	} else {
+		result, err = r.next.Recognize(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.store(key, result)
+	return result, nil
+}
+
+func (r *cachingRecognizer) RecognizeBatch(ctx context.Context, reqs []*RecognizeRequest) ([]*RecognizeResult, error) {
+	return recognizeBatch(ctx, r, reqs)
+}
+
+// ---------- 重试 ----------
+
+// StatusError 带HTTP状态码的请求错误，服务端的业务错误码按含义归入对应的状态码，本地校验失败为400
+type StatusError struct {
+	Code int
+	Err  error
+}
+
+func (e *StatusError) Error() string { return e.Err.Error() }
+
+func (e *StatusError) Unwrap() error { return e.Err }
+
+// invalidRequest 将本地校验失败包装为400错误，重试不会成功
+func invalidRequest(err error) error {
+	return &StatusError{Code: http.StatusBadRequest, Err: err}
+}
+
+// retryableStatus 状态码未知(网络错误)或为5xx时可以重试，4xx说明请求本身有问题
+func retryableStatus(code int) bool {
+	return code == 0 || code >= http.StatusInternalServerError
+}
+
+// retryable 判断错误是否值得重试：未归类的网络错误和5xx错误可以重试，
+// 4xx、本地校验失败以及训练失败等终止状态不重试
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return retryableStatus(statusErr.Code)
+	}
+	var fileErr *FileAsrError
+	if errors.As(err, &fileErr) {
+		return fileErr.retryable()
+	}
+	var speakerErr *SpeakerError
+	var qualityErr *QualityError
+	return !errors.As(err, &speakerErr) && !errors.As(err, &qualityErr)
+}
+
+// retry 以指数退避重试fn，错误不可重试(见retryable)或canRetry返回false时不再重试
+func retry(ctx context.Context, attempts int, backoff time.Duration, canRetry func() bool, fn func() error) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(); err == nil || ctx.Err() != nil || !retryable(err) || !canRetry() {
+			return err
+		}
+		if i == attempts-1 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff << i):
+		}
+	}
+	return err
+}
+
+type retryingSynth struct {
+	next     Synthesizer
+	attempts int
+	backoff  time.Duration
+}
+
+func (s *retryingSynth) Fingerprint() string { return synthFingerprint(s.next) }
+
+// SynthRetry 网络错误和服务端5xx错误时最多尝试attempts次，间隔从backoff开始翻倍
+// 流式合成只有在还没有向下游写出数据时才会重试
+func SynthRetry(attempts int, backoff time.Duration) SynthMiddleware {
+	return func(next Synthesizer) Synthesizer {
+		return &retryingSynth{next: next, attempts: attempts, backoff: backoff}
+	}
+}
+
+func (s *retryingSynth) Synthesize(ctx context.Context, req *SynthRequest) (*SynthResult, error) {
+	var result *SynthResult
+	err := retry(ctx, s.attempts, s.backoff, func() bool { return true }, func() error {
+		var err error
+		result, err = s.next.Synthesize(ctx, req)
+		return err
+	})
+	return result, err
+}
+
+func (s *retryingSynth) SynthesizeStream(ctx context.Context, req *SynthRequest, w io.Writer) error {
+	cw := &countingWriter{w: w}
+	return retry(ctx, s.attempts, s.backoff, func() bool { return cw.n == 0 }, func() error {
+		return s.next.SynthesizeStream(ctx, req, cw)
+	})
+}
+
+func (s *retryingSynth) SynthesizeBatch(ctx context.Context, reqs []*SynthRequest) ([]*SynthResult, error) {
+	return synthesizeBatch(ctx, s, reqs)
+}
+
+type retryingRecognizer struct {
+	next     Recognizer
+	attempts int
+	backoff  time.Duration
+}
+
+func (r *retryingRecognizer) Fingerprint() string { return fingerprint(r.next) }
+
+// RecognizerRetry 网络错误和服务端5xx错误时最多尝试attempts次，间隔从backoff开始翻倍
+// 以io.Reader提供音频时，只有实现了io.Seeker才能重试
+func RecognizerRetry(attempts int, backoff time.Duration) RecognizerMiddleware {
+	return func(next Recognizer) Recognizer {
+		return &retryingRecognizer{next: next, attempts: attempts, backoff: backoff}
+	}
+}
+
+// rewind 将请求中的音频恢复到开头，无法恢复时返回false
+func rewind(req *RecognizeRequest) bool {
+	if req.Audio == nil {
+		return true
+	}
+	seeker, ok := req.Audio.(io.Seeker)
+	if !ok {
+		return false
+	}
+	_, err := seeker.Seek(0, io.SeekStart)
+	return err == nil
+}
+
+func (r *retryingRecognizer) Recognize(ctx context.Context, req *RecognizeRequest) (*RecognizeResult, error) {
+	var result *RecognizeResult
+	err := retry(ctx, r.attempts, r.backoff, func() bool { return rewind(req) }, func() error {
+		var err error
+		result, err = r.next.Recognize(ctx, req)
+		return err
+	})
+	return result, err
+}
+
+func (r *retryingRecognizer) RecognizeStreaming(ctx context.Context, req *RecognizeRequest, onResult func(*RecognizeResult)) (*RecognizeResult, error) {
+	delivered := false
+	wrapped := onResult
+	if onResult != nil {
+		wrapped = func(result *RecognizeResult) {
+			delivered = true
+			onResult(result)
+		}
+	}
+
+	var result *RecognizeResult
+	err := retry(ctx, r.attempts, r.backoff, func() bool { return !delivered && rewind(req) }, func() error {
+		var err error
+		result, err = r.next.RecognizeStreaming(ctx, req, wrapped)
+		return err
+	})
+	return result, err
+}
+
+func (r *retryingRecognizer) RecognizeBatch(ctx context.Context, reqs []*RecognizeRequest) ([]*RecognizeResult, error) {
+	return recognizeBatch(ctx, r, reqs)
+}
+
+// ---------- 指标 ----------
+
+// Metrics 调用次数、失败次数和累计耗时，可被多个中间件共享
+type Metrics struct {
+	requests atomic.Int64
+	failures atomic.Int64
+	latency  atomic.Int64 // 纳秒
+}
+
+// MetricsSnapshot Metrics某一时刻的快照
+type MetricsSnapshot struct {
+	Requests   int64
+	Failures   int64
+	AvgLatency time.Duration
+}
+
+// Snapshot 返回当前指标
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	snap := MetricsSnapshot{Requests: m.requests.Load(), Failures: m.failures.Load()}
+	if snap.Requests > 0 {
+		snap.AvgLatency = time.Duration(m.latency.Load() / snap.Requests)
+	}
+	return snap
+}
+
+func (m *Metrics) observe(start time.Time, err error) {
+	m.requests.Add(1)
+	m.latency.Add(int64(time.Since(start)))
+	if err != nil {
+		m.failures.Add(1)
+	}
+}
+
+type metricsSynth struct {
+	next    Synthesizer
+	metrics *Metrics
+}
+
+func (s *metricsSynth) Fingerprint() string { return synthFingerprint(s.next) }
+
+// SynthMetrics 将每次合成计入metrics
+func SynthMetrics(metrics *Metrics) SynthMiddleware {
+	return func(next Synthesizer) Synthesizer {
+		return &metricsSynth{next: next, metrics: metrics}
+	}
+}
+
+func (s *metricsSynth) Synthesize(ctx context.Context, req *SynthRequest) (*SynthResult, error) {
+	start := time.Now()
+	result, err := s.next.Synthesize(ctx, req)
+	s.metrics.observe(start, err)
+	return result, err
+}
+
+func (s *metricsSynth) SynthesizeStream(ctx context.Context, req *SynthRequest, w io.Writer) error {
+	start := time.Now()
+	err := s.next.SynthesizeStream(ctx, req, w)
+	s.metrics.observe(start, err)
+	return err
+}
+
+func (s *metricsSynth) SynthesizeBatch(ctx context.Context, reqs []*SynthRequest) ([]*SynthResult, error) {
+	return synthesizeBatch(ctx, s, reqs)
+}
+
+type metricsRecognizer struct {
+	next    Recognizer
+	metrics *Metrics
+}
+
+func (r *metricsRecognizer) Fingerprint() string { return fingerprint(r.next) }
+
+// RecognizerMetrics 将每次识别计入metrics
+func RecognizerMetrics(metrics *Metrics) RecognizerMiddleware {
+	return func(next Recognizer) Recognizer {
+		return &metricsRecognizer{next: next, metrics: metrics}
+	}
+}
+
+func (r *metricsRecognizer) Recognize(ctx context.Context, req *RecognizeRequest) (*RecognizeResult, error) {
+	start := time.Now()
+	result, err := r.next.Recognize(ctx, req)
+	r.metrics.observe(start, err)
+	return result, err
+}
+
+func (r *metricsRecognizer) RecognizeStreaming(ctx context.Context, req *RecognizeRequest, onResult func(*RecognizeResult)) (*RecognizeResult, error) {
+	start := time.Now()
+	result, err := r.next.RecognizeStreaming(ctx, req, onResult)
+	r.metrics.observe(start, err)
+	return result, err
+}
+
+func (r *metricsRecognizer) RecognizeBatch(ctx context.Context, reqs []*RecognizeRequest) ([]*RecognizeResult, error) {
+	return recognizeBatch(ctx, r, reqs)
+}
+
+// countingWriter 统计写出的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
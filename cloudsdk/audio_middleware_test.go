@@ -0,0 +1,265 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakySynth 前failures次调用失败，之后委托给离线合成器
+type flakySynth struct {
+	DryRunSynthesizer
+	failures    int32
+	calls       atomic.Int32
+	fingerprint string
+}
+
+func (f *flakySynth) Fingerprint() string { return f.fingerprint }
+
+func (f *flakySynth) Synthesize(ctx context.Context, req *SynthRequest) (*SynthResult, error) {
+	if f.calls.Add(1) <= f.failures {
+		return nil, errors.New("temporary failure")
+	}
+	return f.DryRunSynthesizer.Synthesize(ctx, req)
+}
+
+// fakeRecognizer 返回音频内容作为识别文本
+type fakeRecognizer struct {
+	calls       atomic.Int32
+	fingerprint string
+}
+
+func (f *fakeRecognizer) Fingerprint() string { return f.fingerprint }
+
+func (f *fakeRecognizer) Recognize(ctx context.Context, req *RecognizeRequest) (*RecognizeResult, error) {
+	return f.RecognizeStreaming(ctx, req, nil)
+}
+
+func (f *fakeRecognizer) RecognizeStreaming(ctx context.Context, req *RecognizeRequest, onResult func(*RecognizeResult)) (*RecognizeResult, error) {
+	f.calls.Add(1)
+	audio, err := req.openAudio()
+	if err != nil {
+		return nil, err
+	}
+	defer audio.Close()
+	data, err := io.ReadAll(audio)
+	if err != nil {
+		return nil, err
+	}
+	result := &RecognizeResult{Text: string(data), IsFinal: true}
+	if onResult != nil {
+		onResult(result)
+	}
+	return result, nil
+}
+
+func (f *fakeRecognizer) RecognizeBatch(ctx context.Context, reqs []*RecognizeRequest) ([]*RecognizeResult, error) {
+	return recognizeBatch(ctx, f, reqs)
+}
+
+func TestChainSynthesizer(t *testing.T) {
+	var logs bytes.Buffer
+	metrics := &Metrics{}
+	base := &flakySynth{failures: 1}
+	synth := ChainSynthesizer(base,
+		SynthLogging(log.New(&logs, "", 0)),
+		SynthMetrics(metrics),
+		SynthCache(NewMemoryCache(10)),
+		SynthRetry(3, time.Millisecond),
+	)
+
+	req := &SynthRequest{Text: "你好", Encoding: "pcm"}
+	first, err := synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, base.calls.Load())
+
+	// 第二次命中缓存，不再调用底层合成器
+	second, err := synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, first.Audio, second.Audio)
+	assert.EqualValues(t, 2, base.calls.Load())
+
+	results, err := synth.SynthesizeBatch(context.Background(), []*SynthRequest{req, {Text: "世界", Encoding: "pcm"}})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, first.Audio, results[0].Audio)
+
+	snap := metrics.Snapshot()
+	assert.EqualValues(t, 4, snap.Requests)
+	assert.Zero(t, snap.Failures)
+	assert.Equal(t, 4, strings.Count(logs.String(), "synthesize 2 chars"))
+}
+
+func TestSynthCache_Key(t *testing.T) {
+	// 默认音色不同的合成器共用缓存时互不命中
+	cache := NewMemoryCache(10)
+	a := &flakySynth{fingerprint: "voice-a"}
+	b := &flakySynth{fingerprint: "voice-b"}
+	req := &SynthRequest{Text: "你好"}
+	for _, base := range []*flakySynth{a, b, a} {
+		synth := ChainSynthesizer(base, SynthCache(cache))
+		_, err := synth.Synthesize(context.Background(), req)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, a.calls.Load())
+	assert.EqualValues(t, 1, b.calls.Load())
+}
+
+func TestTTSWsClient_Fingerprint(t *testing.T) {
+	fp := NewTTSWsClient("appid", "secret", "volcano_tts").Fingerprint()
+	assert.NotContains(t, fp, "secret")
+	assert.NotEqual(t, fp, NewTTSWsClient("appid", "secret", "volcano_tts").WithVoiceType("BV700").Fingerprint())
+	assert.NotEqual(t, fp, NewTTSWsClient("appid", "secret", "volcano_icl").Fingerprint())
+	assert.Equal(t, fp, NewTTSWsClient("appid", "other", "volcano_tts").Fingerprint())
+}
+
+func TestSynthRetry_GivesUp(t *testing.T) {
+	base := &flakySynth{failures: 5}
+	synth := ChainSynthesizer(base, SynthRetry(3, time.Millisecond))
+	_, err := synth.Synthesize(context.Background(), &SynthRequest{Text: "你好"})
+	assert.Error(t, err)
+	assert.EqualValues(t, 3, base.calls.Load())
+}
+
+// countingRecognizer 统计Recognize的调用次数
+type countingRecognizer struct {
+	Recognizer
+	calls atomic.Int32
+}
+
+func (c *countingRecognizer) Recognize(ctx context.Context, req *RecognizeRequest) (*RecognizeResult, error) {
+	c.calls.Add(1)
+	return c.Recognizer.Recognize(ctx, req)
+}
+
+func TestSynthRetry_Permanent(t *testing.T) {
+	// 本地校验失败重试也不会成功，只尝试一次
+	base := &flakySynth{}
+	synth := ChainSynthesizer(base, SynthRetry(3, time.Millisecond))
+	_, err := synth.Synthesize(context.Background(), &SynthRequest{Text: "你好", Encoding: "flac"})
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, 400, statusErr.Code)
+	assert.EqualValues(t, 1, base.calls.Load())
+
+	_, err = NewTTSWsClient("appid", "token", "volcano_tts").Synthesize(context.Background(), &SynthRequest{Text: " "})
+	assert.ErrorContains(t, err, "empty text")
+	assert.False(t, retryable(err))
+
+	// 鉴权失败等4xx不重试，网络错误和5xx重试
+	assert.False(t, retryable(fmt.Errorf("synthesis failed: %w", &StatusError{Code: 401, Err: errors.New("unauthorized")})))
+	assert.False(t, retryable(&StatusError{Code: ttsErrorStatus(3011), Err: errors.New("invalid text")}))
+	assert.True(t, retryable(&StatusError{Code: ttsErrorStatus(3031), Err: errors.New("processing error")}))
+	assert.True(t, retryable(errors.New("connection reset")))
+}
+
+func TestRecognizerRetry_Permanent(t *testing.T) {
+	server := newFakeAsrServer(t)
+	server.errorCode = 45000001
+	base := &countingRecognizer{Recognizer: newTestAsrClient(server, "pcm")}
+	recognizer := ChainRecognizer(base, RecognizerRetry(3, time.Millisecond))
+	_, err := recognizer.Recognize(context.Background(), &RecognizeRequest{Audio: bytes.NewReader(make([]byte, 3200))})
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, 400, statusErr.Code)
+	assert.EqualValues(t, 1, base.calls.Load())
+}
+
+func TestChainRecognizer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audio.pcm")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+
+	base := &fakeRecognizer{}
+	metrics := &Metrics{}
+	recognizer := ChainRecognizer(base,
+		RecognizerLogging(log.New(&bytes.Buffer{}, "", 0)),
+		RecognizerMetrics(metrics),
+		RecognizerCache(NewMemoryCache(10)),
+		RecognizerRetry(2, time.Millisecond),
+	)
+
+	var partials []string
+	result, err := recognizer.RecognizeStreaming(context.Background(), &RecognizeRequest{AudioPath: path},
+		func(r *RecognizeResult) { partials = append(partials, r.Text) })
+	require.NoError(t, err)
+	assert.Equal(t, "hello", result.Text)
+	assert.Equal(t, []string{"hello"}, partials)
+
+	results, err := recognizer.RecognizeBatch(context.Background(), []*RecognizeRequest{
+		{AudioPath: path},
+		{Audio: strings.NewReader("world")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", results[0].Text)
+	assert.Equal(t, "world", results[1].Text)
+	assert.EqualValues(t, 2, base.calls.Load())
+	assert.EqualValues(t, 3, metrics.Snapshot().Requests)
+}
+
+func TestRecognizerCache_Key(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audio.pcm")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+
+	// 配置不同的识别器共用缓存时互不命中
+	cache := NewMemoryCache(10)
+	a := &fakeRecognizer{fingerprint: "a"}
+	b := &fakeRecognizer{fingerprint: "b"}
+	for _, base := range []*fakeRecognizer{a, b, a} {
+		_, err := ChainRecognizer(base, RecognizerCache(cache)).Recognize(context.Background(), &RecognizeRequest{AudioPath: path})
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, a.calls.Load())
+	assert.EqualValues(t, 1, b.calls.Load())
+
+	// 请求的格式参数也参与缓存键
+	_, err := ChainRecognizer(a, RecognizerCache(cache)).Recognize(context.Background(), &RecognizeRequest{AudioPath: path, SampleRate: 8000})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, a.calls.Load())
+}
+
+func TestAsrWsClient_Fingerprint(t *testing.T) {
+	config := &AsrConfig{Format: "pcm", Rate: 16000, AccessKey: "secret"}
+	fp := NewAsrWsClient(config).Fingerprint()
+	assert.NotContains(t, fp, "secret")
+
+	withHotWords := *config
+	withHotWords.HotWordList = []string{"火山"}
+	assert.NotEqual(t, fp, NewAsrWsClient(&withHotWords).Fingerprint())
+
+	otherKey := *config
+	otherKey.AccessKey = "other"
+	assert.Equal(t, fp, NewAsrWsClient(&otherKey).Fingerprint())
+}
+
+func TestMemoryCache_Evicts(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", []byte("1"))
+	cache.Set("b", []byte("2"))
+	cache.Get("a")
+	cache.Set("c", []byte("3"))
+
+	_, ok := cache.Get("b")
+	assert.False(t, ok)
+	v, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+}
+
+// 确保具体实现满足接口
+var (
+	_ Synthesizer = (*TTSWsClient)(nil)
+	_ Synthesizer = (*DryRunSynthesizer)(nil)
+	_ Recognizer  = (*AsrWsClient)(nil)
+)
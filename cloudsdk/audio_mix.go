@@ -0,0 +1,192 @@
+package cloudsdk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MixOptions 背景音乐混音参数
+type MixOptions struct {
+	MusicGainDB       *float64 // 背景音乐整体增益，默认 -18dB，DB(0)表示原始音量
+	DuckGainDB        *float64 // 有人声时额外的衰减，默认 -12dB，DB(0)表示不压低
+	SpeechThresholdDB float64  // 人声检测的帧能量阈值(dBFS)，默认 -45dB
+	FrameMs           int      // 能量检测的帧长，默认 20ms
+	AttackMs          int      // 开始压低音乐的过渡时间，默认 50ms
+	ReleaseMs         int      // 人声结束后恢复音乐的过渡时间，默认 400ms
+	NoLoop            bool     // 背景音乐比旁白短时不循环，剩余部分留空
+}
+
+func (o *MixOptions) withDefaults() MixOptions {
+	opts := *o
+	if opts.MusicGainDB == nil {
+		opts.MusicGainDB = DB(-18)
+	}
+	if opts.DuckGainDB == nil {
+		opts.DuckGainDB = DB(-12)
+	}
+	if opts.SpeechThresholdDB == 0 {
+		opts.SpeechThresholdDB = -45
+	}
+	if opts.FrameMs <= 0 {
+		opts.FrameMs = 20
+	}
+	if opts.AttackMs <= 0 {
+		opts.AttackMs = 50
+	}
+	if opts.ReleaseMs <= 0 {
+		opts.ReleaseMs = 400
+	}
+	return opts
+}
+
+// DB 返回指向分贝值的指针，用于设置可以为0的分贝参数
+func DB(v float64) *float64 {
+	return &v
+}
+
+// dbToGain 将分贝转换为线性增益
+func dbToGain(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// clampInt16 将浮点采样值截断到int16范围
+func clampInt16(v float64) int16 {
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(math.Round(v))
+}
+
+// frameEnergyDB 计算一段交错采样的RMS能量(dBFS)
+func frameEnergyDB(samples []int16) float64 {
+	if len(samples) == 0 {
+		return math.Inf(-1)
+	}
+	var sum float64
+	for _, s := range samples {
+		v := float64(s) / 32768
+		sum += v * v
+	}
+	rms := math.Sqrt(sum / float64(len(samples)))
+	if rms == 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(rms)
+}
+
+// remixChannels 将音频转换为指定声道数，单声道复制到各声道，多声道取平均
+func remixChannels(a *PCMAudio, channels int) *PCMAudio {
+	if a.Channels == channels {
+		return a
+	}
+	frames := a.Frames()
+	out := &PCMAudio{SampleRate: a.SampleRate, Channels: channels, Samples: make([]int16, frames*channels)}
+	for i := 0; i < frames; i++ {
+		var sum int
+		for c := 0; c < a.Channels; c++ {
+			sum += int(a.Samples[i*a.Channels+c])
+		}
+		v := int16(sum / a.Channels)
+		for c := 0; c < channels; c++ {
+			out.Samples[i*channels+c] = v
+		}
+	}
+	return out
+}
+
+// MixBackground 将背景音乐铺在旁白下方，并在检测到人声时压低音乐(ducking)
+// 输出与旁白等长，声道数和采样率与旁白一致，背景音乐的采样率和声道数不同时先转换
+func MixBackground(narration, music *PCMAudio, opts MixOptions) (*PCMAudio, error) {
+	if narration == nil || music == nil {
+		return nil, errors.New("narration and music are required")
+	}
+	if narration.Channels <= 0 || music.Channels <= 0 {
+		return nil, errors.New("invalid channel count")
+	}
+	if narration.SampleRate <= 0 || music.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate: narration %d Hz, music %d Hz",
+			narration.SampleRate, music.SampleRate)
+	}
+	if music.Frames() == 0 {
+		return nil, errors.New("music bed is empty")
+	}
+	o := opts.withDefaults()
+
+	channels := narration.Channels
+	music = Resample(remixChannels(music, channels), narration.SampleRate)
+	frames := narration.Frames()
+	frameLen := narration.SampleRate * o.FrameMs / 1000
+	if frameLen == 0 {
+		frameLen = 1
+	}
+
+	// 逐帧检测人声，得到每帧的目标增益
+	fullGain := dbToGain(*o.MusicGainDB)
+	duckedGain := dbToGain(*o.MusicGainDB + *o.DuckGainDB)
+	targets := make([]float64, (frames+frameLen-1)/frameLen)
+	for i := range targets {
+		start := i * frameLen * channels
+		end := min((i+1)*frameLen*channels, len(narration.Samples))
+		targets[i] = fullGain
+		if frameEnergyDB(narration.Samples[start:end]) > o.SpeechThresholdDB {
+			targets[i] = duckedGain
+		}
+	}
+
+	// 一阶平滑，避免增益突变产生爆音
+	attack := math.Exp(-1 / (float64(o.AttackMs) / 1000 * float64(narration.SampleRate)))
+	release := math.Exp(-1 / (float64(o.ReleaseMs) / 1000 * float64(narration.SampleRate)))
+	gain := fullGain
+	if len(targets) > 0 {
+		gain = targets[0]
+	}
+
+	out := &PCMAudio{SampleRate: narration.SampleRate, Channels: channels, Samples: make([]int16, frames*channels)}
+	musicFrames := music.Frames()
+	for i := 0; i < frames; i++ {
+		target := targets[i/frameLen]
+		coef := release
+		if target < gain {
+			coef = attack
+		}
+		gain = target + (gain-target)*coef
+
+		mi := i
+		if mi >= musicFrames {
+			if o.NoLoop {
+				mi = -1
+			} else {
+				mi %= musicFrames
+			}
+		}
+		for c := 0; c < channels; c++ {
+			v := float64(narration.Samples[i*channels+c])
+			if mi >= 0 {
+				v += float64(music.Samples[mi*channels+c]) * gain
+			}
+			out.Samples[i*channels+c] = clampInt16(v)
+		}
+	}
+	return out, nil
+}
+
+// MixBackgroundFiles 读取旁白和背景音乐WAV文件，混音后写入outFile
+func MixBackgroundFiles(narrationFile, musicFile, outFile string, opts MixOptions) error {
+	narration, err := ReadWAVFile(narrationFile)
+	if err != nil {
+		return fmt.Errorf("load narration failed: %v", err)
+	}
+	music, err := ReadWAVFile(musicFile)
+	if err != nil {
+		return fmt.Errorf("load music failed: %v", err)
+	}
+	mixed, err := MixBackground(narration, music, opts)
+	if err != nil {
+		return err
+	}
+	return mixed.WriteWAVFile(outFile)
+}
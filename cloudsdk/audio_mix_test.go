@@ -0,0 +1,76 @@
+package cloudsdk
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sineTone 生成指定频率和幅度的单声道正弦波
+func sineTone(rate, frames int, freq, amplitude float64) *PCMAudio {
+	a := &PCMAudio{SampleRate: rate, Channels: 1, Samples: make([]int16, frames)}
+	for i := range a.Samples {
+		a.Samples[i] = int16(amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return a
+}
+
+func TestMixBackground_Ducking(t *testing.T) {
+	rate := 16000
+	// 前一秒有人声，后一秒静音
+	narration := sineTone(rate, 2*rate, 300, 8000)
+	for i := rate; i < 2*rate; i++ {
+		narration.Samples[i] = 0
+	}
+	// 背景音乐只有0.5秒，需要循环
+	music := sineTone(rate, rate/2, 1000, 16000)
+
+	mixed, err := MixBackground(narration, music, MixOptions{ReleaseMs: 100})
+	require.NoError(t, err)
+	require.Equal(t, narration.Frames(), mixed.Frames())
+
+	// 语音段中减去旁白即为音乐分量
+	var speechMusic []int16
+	for i := rate / 2; i < rate; i++ {
+		speechMusic = append(speechMusic, mixed.Samples[i]-narration.Samples[i])
+	}
+	quiet := mixed.Samples[rate+rate/2:]
+
+	ducked := frameEnergyDB(speechMusic)
+	full := frameEnergyDB(quiet)
+	assert.InDelta(t, 12, full-ducked, 1.5)
+	assert.InDelta(t, -18, full-frameEnergyDB(music.Samples), 1)
+}
+
+func TestMixBackground_UnityGain(t *testing.T) {
+	narration := sineTone(16000, 16000, 300, 4000)
+	music := sineTone(16000, 16000, 1000, 4000)
+
+	// 显式的0dB表示原始音量且不压低，而不是使用默认值
+	mixed, err := MixBackground(narration, music, MixOptions{MusicGainDB: DB(0), DuckGainDB: DB(0)})
+	require.NoError(t, err)
+	for i := range mixed.Samples {
+		assert.InDelta(t, int(narration.Samples[i])+int(music.Samples[i]), mixed.Samples[i], 1, "sample %d", i)
+	}
+}
+
+func TestMixBackground_Resample(t *testing.T) {
+	// 24kHz的TTS旁白配44.1kHz的立体声背景音乐
+	narration := &PCMAudio{SampleRate: 24000, Channels: 1, Samples: make([]int16, 24000)}
+	music := remixChannels(sineTone(44100, 44100, 1000, 8000), 2)
+
+	mixed, err := MixBackground(narration, music, MixOptions{MusicGainDB: DB(0)})
+	require.NoError(t, err)
+	assert.Equal(t, 24000, mixed.SampleRate)
+	assert.Equal(t, 1, mixed.Channels)
+	assert.Equal(t, narration.Frames(), mixed.Frames())
+	// 旁白静音，输出即为转换后的音乐，电平和频率保持不变
+	assert.InDelta(t, frameEnergyDB(music.Samples), frameEnergyDB(mixed.Samples[1000:23000]), 0.5)
+	want := sineTone(24000, 24000, 1000, 8000)
+	assert.Greater(t, snrDB(want.Samples[1000:23000], mixed.Samples[1000:23000], 0), 30.0)
+
+	_, err = MixBackground(narration, &PCMAudio{Channels: 1, Samples: []int16{1}}, MixOptions{})
+	assert.ErrorContains(t, err, "invalid sample rate")
+}
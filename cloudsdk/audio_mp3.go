@@ -0,0 +1,256 @@
+package cloudsdk
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MPEG音频版本
+const (
+	mpegVersion25 = 0
+	mpegVersion2  = 2
+	mpegVersion1  = 3
+)
+
+var (
+	// mp3BitrateTable 按 [是否MPEG1][层-1][比特率索引] 给出 kbps
+	mp3BitrateTable = [2][3][16]int{
+		{ // MPEG2 / MPEG2.5
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		},
+		{ // MPEG1
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		},
+	}
+	// mp3SampleRateTable 按版本索引给出采样率
+	mp3SampleRateTable = map[int][3]int{
+		mpegVersion1:  {44100, 48000, 32000},
+		mpegVersion2:  {22050, 24000, 16000},
+		mpegVersion25: {11025, 12000, 8000},
+	}
+)
+
+// mp3FrameHeader 描述一个MPEG音频帧头
+type mp3FrameHeader struct {
+	Version    int
+	Layer      int
+	Bitrate    int // kbps
+	SampleRate int
+	Channels   int
+	Samples    int // 每帧采样数
+	Size       int // 整帧字节数(含帧头)
+}
+
+// Duration 返回单帧的播放时长
+func (h mp3FrameHeader) Duration() time.Duration {
+	return time.Duration(h.Samples) * time.Second / time.Duration(h.SampleRate)
+}
+
+// parseMP3FrameHeader 解析4字节的帧头，不合法时返回false
+func parseMP3FrameHeader(b []byte) (mp3FrameHeader, bool) {
+	h := mp3FrameHeader{}
+	if len(b) < 4 || b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return h, false
+	}
+
+	h.Version = int(b[1]>>3) & 0x03
+	layerBits := int(b[1]>>1) & 0x03
+	bitrateIndex := int(b[2] >> 4)
+	sampleRateIndex := int(b[2]>>2) & 0x03
+	padding := int(b[2]>>1) & 0x01
+	channelMode := int(b[3] >> 6)
+
+	if h.Version == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return h, false
+	}
+	h.Layer = 4 - layerBits
+
+	isMPEG1 := 0
+	if h.Version == mpegVersion1 {
+		isMPEG1 = 1
+	}
+	h.Bitrate = mp3BitrateTable[isMPEG1][h.Layer-1][bitrateIndex]
+	h.SampleRate = mp3SampleRateTable[h.Version][sampleRateIndex]
+	h.Channels = 2
+	if channelMode == 3 {
+		h.Channels = 1
+	}
+
+	switch {
+	case h.Layer == 1:
+		h.Samples = 384
+		h.Size = (12*h.Bitrate*1000/h.SampleRate + padding) * 4
+	case h.Layer == 3 && h.Version != mpegVersion1:
+		h.Samples = 576
+		h.Size = 72*h.Bitrate*1000/h.SampleRate + padding
+	default:
+		h.Samples = 1152
+		h.Size = 144*h.Bitrate*1000/h.SampleRate + padding
+	}
+	return h, true
+}
+
+// id3v2Size 返回数据开头ID3v2标签的总长度，没有标签时返回0
+func id3v2Size(data []byte) int {
+	if len(data) < 10 || string(data[0:3]) != "ID3" {
+		return 0
+	}
+	size := decodeSynchsafe(data[6:10]) + 10
+	if data[5]&0x10 != 0 { // footer present
+		size += 10
+	}
+	if size > len(data) {
+		return len(data)
+	}
+	return size
+}
+
+// stripID3 去掉MP3数据首部的ID3v2标签和尾部的ID3v1标签
+func stripID3(data []byte) []byte {
+	data = data[id3v2Size(data):]
+	if len(data) >= 128 && string(data[len(data)-128:len(data)-125]) == "TAG" {
+		data = data[:len(data)-128]
+	}
+	return data
+}
+
+// mp3SideInfoSize 返回Layer III帧头之后side info的长度，Xing/Info标签紧随其后
+func mp3SideInfoSize(h mp3FrameHeader) int {
+	switch {
+	case h.Version == mpegVersion1 && h.Channels == 1:
+		return 17
+	case h.Version == mpegVersion1:
+		return 32
+	case h.Channels == 1:
+		return 9
+	}
+	return 17
+}
+
+// isMP3InfoFrame 判断帧是否为Xing/Info/VBRI信息帧(不含音频)
+func isMP3InfoFrame(h mp3FrameHeader, frame []byte) bool {
+	if h.Layer != 3 {
+		return false
+	}
+	if pos := 4 + mp3SideInfoSize(h); pos+4 <= len(frame) {
+		if tag := string(frame[pos : pos+4]); tag == "Xing" || tag == "Info" {
+			return true
+		}
+	}
+	// VBRI固定位于帧头后32字节
+	pos := 4 + 32
+	return pos+4 <= len(frame) && string(frame[pos:pos+4]) == "VBRI"
+}
+
+// mp3FrameReader 从流中逐帧读取MPEG音频，跳过ID3标签、Xing/VBRI信息帧和帧间的无效数据
+type mp3FrameReader struct {
+	r       *bufio.Reader
+	started bool
+	synced  bool // 上一帧之后紧接着就是合法帧头
+}
+
+func newMP3FrameReader(r io.Reader) *mp3FrameReader {
+	return &mp3FrameReader{r: bufio.NewReaderSize(r, 8192)}
+}
+
+// skipID3v2 跳过流开头的ID3v2标签
+func (m *mp3FrameReader) skipID3v2() error {
+	hdr, err := m.r.Peek(10)
+	if err != nil || string(hdr[0:3]) != "ID3" {
+		return nil
+	}
+	size := decodeSynchsafe(hdr[6:10]) + 10
+	if hdr[5]&0x10 != 0 {
+		size += 10
+	}
+	if _, err := m.r.Discard(size); err != nil {
+		return fmt.Errorf("failed to skip id3 tag: %v", err)
+	}
+	return nil
+}
+
+// next 返回下一个音频帧，没有更多完整帧时返回io.EOF
+func (m *mp3FrameReader) next() (mp3FrameHeader, []byte, error) {
+	if !m.started {
+		m.started = true
+		if err := m.skipID3v2(); err != nil {
+			return mp3FrameHeader{}, nil, err
+		}
+	}
+
+	for {
+		b, err := m.r.Peek(4)
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF || errors.Is(err, bufio.ErrBufferFull) {
+				return mp3FrameHeader{}, nil, io.EOF
+			}
+			return mp3FrameHeader{}, nil, fmt.Errorf("failed to read mp3 frame: %v", err)
+		}
+		if string(b[0:3]) == "TAG" {
+			// ID3v1标签固定128字节
+			m.r.Discard(128)
+			continue
+		}
+		h, ok := parseMP3FrameHeader(b)
+		if ok && !m.synced {
+			// 重新同步时要求下一帧的帧头也合法，避免把数据中偶然出现的同步字当成帧头
+			if peek, err := m.r.Peek(h.Size + 4); err == nil {
+				_, ok = parseMP3FrameHeader(peek[h.Size:])
+			}
+		}
+		if !ok {
+			m.synced = false
+			m.r.Discard(1)
+			continue
+		}
+
+		frame := make([]byte, h.Size)
+		if _, err := io.ReadFull(m.r, frame); err != nil {
+			// 末尾被截断的帧无法解码，直接丢弃
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return mp3FrameHeader{}, nil, io.EOF
+			}
+			return mp3FrameHeader{}, nil, fmt.Errorf("failed to read mp3 frame: %v", err)
+		}
+		m.synced = true
+
+		if isMP3InfoFrame(h, frame) {
+			continue
+		}
+		return h, frame, nil
+	}
+}
+
+// mp3Duration 逐帧扫描MP3数据并累加播放时长，Xing/VBRI信息帧不计入
+func mp3Duration(data []byte) (time.Duration, error) {
+	// 按采样率累计采样点数，避免逐帧取整带来的误差
+	samples := map[int]int64{}
+	frames := newMP3FrameReader(bytes.NewReader(data))
+	for {
+		h, _, err := frames.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		samples[h.SampleRate] += int64(h.Samples)
+	}
+
+	if len(samples) == 0 {
+		return 0, errors.New("no mpeg audio frames found")
+	}
+	var total time.Duration
+	for rate, n := range samples {
+		total += time.Duration(n) * time.Second / time.Duration(rate)
+	}
+	return total, nil
+}
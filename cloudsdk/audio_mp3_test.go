@@ -0,0 +1,97 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// xingFrame 构造一个记录了总帧数的Xing信息帧(MPEG1 Layer III 立体声)
+func xingFrame(frames int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+	copy(frame[4+32:], "Xing")
+	binary.BigEndian.PutUint32(frame[4+32+4:], 0x01)
+	binary.BigEndian.PutUint32(frame[4+32+8:], uint32(frames))
+	return frame
+}
+
+// taggedMP3 在音频帧前后加上ID3v2标签、无效数据、Xing帧和ID3v1标签
+func taggedMP3(frames int) []byte {
+	tag, _ := (&ID3Tag{Title: "测试"}).Bytes()
+	data := append(tag, 0x00, 0xff, 0xfb, 0x12) // 标签后的填充和无效数据
+	data = append(data, xingFrame(frames)...)
+	data = append(data, fakeMP3(frames)...)
+	data = append(data, append([]byte("TAG"), make([]byte, 125)...)...)
+	return data
+}
+
+func TestMP3FrameReader(t *testing.T) {
+	frames := newMP3FrameReader(bytes.NewReader(taggedMP3(10)))
+	n := 0
+	for {
+		h, frame, err := frames.next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Len(t, frame, h.Size)
+		assert.Equal(t, []byte{0xff, 0xfb, 0x90, 0x00}, frame[:4])
+		n++
+	}
+	assert.Equal(t, 10, n)
+
+	// 信息帧不计入时长
+	d, err := mp3Duration(taggedMP3(10))
+	require.NoError(t, err)
+	assert.Equal(t, 10*1152*time.Second/44100, d)
+
+	// 末尾被截断的帧被丢弃
+	d, err = mp3Duration(fakeMP3(3)[:417*3-100])
+	require.NoError(t, err)
+	assert.Equal(t, 2*1152*time.Second/44100, d)
+}
+
+func TestMP3Chunks(t *testing.T) {
+	// 每帧约26.1ms，100ms需要4帧
+	next := mp3Chunks(bytes.NewReader(taggedMP3(10)), 100*time.Millisecond, 0)
+	var sizes []int
+	var total time.Duration
+	for {
+		chunk, d, err := next()
+		sizes = append(sizes, len(chunk))
+		total += d
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, d, 100*time.Millisecond)
+	}
+	assert.Equal(t, []int{417 * 4, 417 * 4, 417 * 2}, sizes)
+	assert.InDelta(t, 10*1152*time.Second/44100, total, float64(time.Microsecond))
+}
+
+func TestAsrWsClient_MP3FrameAligned(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "mp3")
+
+	data := taggedMP3(9)
+	_, err := client.RecognizeReader(context.Background(), bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// 只发送音频帧，每包都从帧头开始并由整帧组成
+	assert.Equal(t, fakeMP3(9), server.Audio())
+	for _, chunk := range server.chunks {
+		if len(chunk) > 0 {
+			assert.Equal(t, byte(0xff), chunk[0])
+			assert.Zero(t, len(chunk)%417)
+		}
+	}
+	assert.Equal(t, "mp3", server.request["audio"].(map[string]interface{})["format"])
+}
@@ -0,0 +1,267 @@
+package cloudsdk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// oggCRCTable Ogg使用的CRC32(多项式0x04c11db7，不反转)查找表
+var oggCRCTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+func oggCRC(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// oggPage 一个Ogg页
+type oggPage struct {
+	HeaderType byte
+	Granule    int64
+	Serial     uint32
+	Sequence   uint32
+	Lacing     []byte // 段表
+	Body       []byte
+	Raw        []byte // 整页原始字节
+}
+
+// Continued 页的第一个包是否延续自上一页
+func (p *oggPage) Continued() bool { return p.HeaderType&0x01 != 0 }
+
+// readOggPage 读取并校验一个Ogg页
+func readOggPage(r io.Reader) (*oggPage, error) {
+	var hdr [27]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read ogg page: %v", err)
+	}
+	if string(hdr[0:4]) != "OggS" {
+		return nil, errors.New("invalid ogg page: missing capture pattern")
+	}
+	if hdr[4] != 0 {
+		return nil, fmt.Errorf("unsupported ogg version %d", hdr[4])
+	}
+
+	lacing := make([]byte, hdr[26])
+	if _, err := io.ReadFull(r, lacing); err != nil {
+		return nil, fmt.Errorf("failed to read ogg segment table: %v", err)
+	}
+	size := 0
+	for _, l := range lacing {
+		size += int(l)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read ogg page body: %v", err)
+	}
+
+	raw := make([]byte, 0, len(hdr)+len(lacing)+len(body))
+	raw = append(append(append(raw, hdr[:]...), lacing...), body...)
+	want := binary.LittleEndian.Uint32(hdr[22:26])
+	binary.LittleEndian.PutUint32(raw[22:26], 0)
+	if got := oggCRC(raw); got != want {
+		return nil, fmt.Errorf("ogg page %d checksum mismatch", binary.LittleEndian.Uint32(hdr[18:22]))
+	}
+	binary.LittleEndian.PutUint32(raw[22:26], want)
+
+	return &oggPage{
+		HeaderType: hdr[5],
+		Granule:    int64(binary.LittleEndian.Uint64(hdr[6:14])),
+		Serial:     binary.LittleEndian.Uint32(hdr[14:18]),
+		Sequence:   binary.LittleEndian.Uint32(hdr[18:22]),
+		Lacing:     lacing,
+		Body:       body,
+		Raw:        raw,
+	}, nil
+}
+
+// OpusHead Ogg/Opus流的标识头
+type OpusHead struct {
+	Channels        int
+	PreSkip         int
+	InputSampleRate int // 编码前的原始采样率，仅供参考，Opus总是以48kHz解码
+	OutputGain      int
+	MappingFamily   int
+}
+
+func parseOpusHead(packet []byte) (*OpusHead, error) {
+	if len(packet) < 19 || string(packet[0:8]) != "OpusHead" {
+		return nil, errors.New("not an ogg/opus stream: missing OpusHead")
+	}
+	if packet[8]>>4 != 0 {
+		return nil, fmt.Errorf("unsupported opus version %d", packet[8])
+	}
+	return &OpusHead{
+		Channels:        int(packet[9]),
+		PreSkip:         int(binary.LittleEndian.Uint16(packet[10:12])),
+		InputSampleRate: int(binary.LittleEndian.Uint32(packet[12:16])),
+		OutputGain:      int(int16(binary.LittleEndian.Uint16(packet[16:18]))),
+		MappingFamily:   int(packet[18]),
+	}, nil
+}
+
+// opusPacketDuration 根据TOC字节计算Opus包的时长
+func opusPacketDuration(packet []byte) time.Duration {
+	if len(packet) == 0 {
+		return 0
+	}
+	config := packet[0] >> 3
+	var frame time.Duration
+	switch {
+	case config < 12: // SILK
+		frame = [4]time.Duration{10, 20, 40, 60}[config%4] * time.Millisecond
+	case config < 16: // Hybrid
+		frame = [2]time.Duration{10, 20}[config%2] * time.Millisecond
+	default: // CELT
+		frame = [4]time.Duration{2500, 5000, 10000, 20000}[config%4] * time.Microsecond
+	}
+
+	frames := 1
+	switch packet[0] & 0x03 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0
+		}
+		frames = int(packet[1] & 0x3f)
+	}
+	return frame * time.Duration(frames)
+}
+
+// OggOpusReader Ogg/Opus解复用器，逐个读出Opus音频包
+type OggOpusReader struct {
+	r       *bufio.Reader
+	started bool
+	serial  uint32
+	partial []byte   // 跨页的未完成包
+	packets [][]byte // 当前页已完成、尚未读出的包
+	pages   []*oggPage
+
+	// Head 流的标识头，NewOggOpusReader返回后即可使用
+	Head *OpusHead
+}
+
+// NewOggOpusReader 读取标识头和注释头，之后ReadPacket返回的都是音频包
+func NewOggOpusReader(r io.Reader) (*OggOpusReader, error) {
+	o := &OggOpusReader{r: bufio.NewReader(r)}
+	head, err := o.nextPacket()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpusHead: %v", err)
+	}
+	if o.Head, err = parseOpusHead(head); err != nil {
+		return nil, err
+	}
+	tags, err := o.nextPacket()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpusTags: %v", err)
+	}
+	if !bytes.HasPrefix(tags, []byte("OpusTags")) {
+		return nil, errors.New("invalid ogg/opus stream: missing OpusTags")
+	}
+	return o, nil
+}
+
+// readPage 读取属于当前逻辑流的下一页，并把其中完成的包加入队列
+func (o *OggOpusReader) readPage() error {
+	for {
+		page, err := readOggPage(o.r)
+		if err != nil {
+			return err
+		}
+		if !o.started {
+			o.started = true
+			o.serial = page.Serial
+		}
+		if page.Serial != o.serial {
+			// 忽略复用在同一文件中的其他逻辑流
+			continue
+		}
+		o.pages = append(o.pages, page)
+
+		if !page.Continued() {
+			o.partial = nil
+		}
+		pos := 0
+		for _, l := range page.Lacing {
+			o.partial = append(o.partial, page.Body[pos:pos+int(l)]...)
+			pos += int(l)
+			if l < 255 {
+				o.packets = append(o.packets, o.partial)
+				o.partial = nil
+			}
+		}
+		return nil
+	}
+}
+
+func (o *OggOpusReader) nextPacket() ([]byte, error) {
+	for len(o.packets) == 0 {
+		if err := o.readPage(); err != nil {
+			return nil, err
+		}
+	}
+	p := o.packets[0]
+	o.packets = o.packets[1:]
+	return p, nil
+}
+
+// ReadPacket 返回下一个Opus音频包及其时长，流结束时返回io.EOF
+func (o *OggOpusReader) ReadPacket() ([]byte, time.Duration, error) {
+	p, err := o.nextPacket()
+	if err != nil {
+		return nil, 0, err
+	}
+	return p, opusPacketDuration(p), nil
+}
+
+// takePages 返回自上次调用以来读取的原始页
+func (o *OggOpusReader) takePages() []byte {
+	var raw []byte
+	for _, p := range o.pages {
+		raw = append(raw, p.Raw...)
+	}
+	o.pages = nil
+	return raw
+}
+
+// oggOpusChunks 按整页分包，每包内的音频时长达到segDuration时发出，第一包包含标识头和注释头
+func oggOpusChunks(o *OggOpusReader, segDuration time.Duration) chunkSource {
+	return func() ([]byte, time.Duration, error) {
+		var d time.Duration
+		for {
+			_, pd, err := o.ReadPacket()
+			if err != nil {
+				return o.takePages(), d, err
+			}
+			d += pd
+			// 只在页尾切分，保证每包都是完整的Ogg页
+			if d >= segDuration && len(o.packets) == 0 && o.partial == nil {
+				return o.takePages(), d, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oggMuxer 测试用的Ogg封装，按最大段数分页，跨页的包设置continued标志
+type oggMuxer struct {
+	buf bytes.Buffer
+	seq uint32
+}
+
+func (m *oggMuxer) writePage(headerType byte, lacing, body []byte) {
+	page := []byte("OggS")
+	page = append(page, 0, headerType)
+	page = binary.LittleEndian.AppendUint64(page, 0)
+	page = binary.LittleEndian.AppendUint32(page, 0x1234)
+	page = binary.LittleEndian.AppendUint32(page, m.seq)
+	page = binary.LittleEndian.AppendUint32(page, 0)
+	page = append(page, byte(len(lacing)))
+	page = append(append(page, lacing...), body...)
+	binary.LittleEndian.PutUint32(page[22:26], oggCRC(page))
+	m.buf.Write(page)
+	m.seq++
+}
+
+// write 将一组包写入若干页，每页最多maxSegments个段
+func (m *oggMuxer) write(packets [][]byte, maxSegments int, headerType byte) {
+	var lacing, body []byte
+	continued := false
+	flush := func(next bool) {
+		ht := headerType
+		if continued {
+			ht |= 0x01
+		}
+		m.writePage(ht, lacing, body)
+		lacing, body, continued = nil, nil, next
+		headerType = 0
+	}
+	for _, p := range packets {
+		for rest := p; ; {
+			n := min(len(rest), 255)
+			lacing = append(lacing, byte(n))
+			body = append(body, rest[:n]...)
+			rest = rest[n:]
+			done := n < 255
+			if len(lacing) == maxSegments {
+				flush(!done)
+			}
+			if done {
+				break
+			}
+		}
+	}
+	if len(lacing) > 0 {
+		flush(false)
+	}
+}
+
+func opusHeadPacket(channels int) []byte {
+	head := []byte("OpusHead")
+	head = append(head, 1, byte(channels))
+	head = binary.LittleEndian.AppendUint16(head, 312)
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	return append(head, 0, 0, 0)
+}
+
+// testOggOpus 构造一个Ogg/Opus流，音频包均为20ms的CELT包，第3个包跨页
+func testOggOpus(packets int) ([]byte, [][]byte) {
+	m := &oggMuxer{}
+	m.write([][]byte{opusHeadPacket(1)}, 255, 0x02)
+	m.write([][]byte{append([]byte("OpusTags"), make([]byte, 8)...)}, 255, 0)
+
+	var audio [][]byte
+	for i := 0; i < packets; i++ {
+		size := 40 + i
+		if i == 2 {
+			size = 600
+		}
+		p := bytes.Repeat([]byte{byte(i)}, size)
+		p[0] = 31 << 3 // CELT 20ms 单帧
+		audio = append(audio, p)
+	}
+	m.write(audio, 4, 0)
+	return m.buf.Bytes(), audio
+}
+
+func TestOggOpusReader(t *testing.T) {
+	data, want := testOggOpus(10)
+	o, err := NewOggOpusReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 1, o.Head.Channels)
+	assert.Equal(t, 312, o.Head.PreSkip)
+	assert.Equal(t, 48000, o.Head.InputSampleRate)
+
+	var got [][]byte
+	for {
+		p, d, err := o.ReadPacket()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, 20*time.Millisecond, d)
+		got = append(got, p)
+	}
+	assert.Equal(t, want, got)
+
+	// 校验和错误
+	data[len(data)-1] ^= 0xff
+	o, err = NewOggOpusReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	for err == nil {
+		_, _, err = o.ReadPacket()
+	}
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	_, err = NewOggOpusReader(bytes.NewReader([]byte("not ogg at all, definitely not")))
+	assert.Error(t, err)
+}
+
+func TestOpusPacketDuration(t *testing.T) {
+	assert.Equal(t, 60*time.Millisecond, opusPacketDuration([]byte{3 << 3}))        // SILK 60ms
+	assert.Equal(t, 20*time.Millisecond, opusPacketDuration([]byte{13 << 3}))       // Hybrid 20ms
+	assert.Equal(t, 5*time.Millisecond, opusPacketDuration([]byte{16<<3 | 1}))      // CELT 2.5ms x2
+	assert.Equal(t, 100*time.Millisecond, opusPacketDuration([]byte{31<<3 | 3, 5})) // CELT 20ms x5
+}
+
+func TestAsrWsClient_OggOpus(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "ogg")
+
+	data, _ := testOggOpus(20)
+	_, err := client.RecognizeReader(context.Background(), bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// 原样发送全部页，每包都由完整的页组成
+	assert.Equal(t, data, server.Audio())
+	for _, chunk := range server.chunks {
+		if len(chunk) > 0 {
+			assert.Equal(t, "OggS", string(chunk[:4]))
+		}
+	}
+	assert.Greater(t, len(server.chunks), 2)
+	audio := server.request["audio"].(map[string]interface{})
+	assert.Equal(t, "ogg", audio["format"])
+	assert.Equal(t, "opus", audio["codec"])
+	assert.Equal(t, float64(1), audio["channel"])
+}
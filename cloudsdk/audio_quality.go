@@ -0,0 +1,217 @@
+package cloudsdk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	qualityFrameMs     = 20
+	clippingLevel      = 32700
+	silenceThresholdDB = -45.0
+)
+
+// AudioQualityReport 训练音频的质量分析结果
+type AudioQualityReport struct {
+	Duration      time.Duration
+	SampleRate    int
+	Channels      int
+	Peak          float64 // 峰值电平，满幅为1
+	ClippingRatio float64 // 削波采样点占比
+	SilenceRatio  float64 // 静音帧占比
+	SNR           float64 // 估计信噪比(dB)
+}
+
+// QualityThresholds 训练音频的质量门限，零值字段表示不检查该项
+type QualityThresholds struct {
+	MinDuration      time.Duration
+	MaxDuration      time.Duration
+	MinSampleRate    int
+	RequireMono      bool
+	MaxClippingRatio float64
+	MaxSilenceRatio  float64
+	MinSNR           float64
+}
+
+// DefaultQualityThresholds 返回声音复刻推荐的质量门限
+func DefaultQualityThresholds() QualityThresholds {
+	return QualityThresholds{
+		MinDuration:      10 * time.Second,
+		MaxDuration:      5 * time.Minute,
+		MinSampleRate:    16000,
+		RequireMono:      true,
+		MaxClippingRatio: 0.001,
+		MaxSilenceRatio:  0.5,
+		MinSNR:           20,
+	}
+}
+
+// QualityError 训练音频未通过质量检查，Problems列出所有不满足的项
+type QualityError struct {
+	Problems []string
+}
+
+func (e *QualityError) Error() string {
+	return "training audio rejected: " + strings.Join(e.Problems, "; ")
+}
+
+// AnalyzeAudioQuality 分析PCM音频的时长、削波、静音比例和信噪比
+func AnalyzeAudioQuality(a *PCMAudio) *AudioQualityReport {
+	report := &AudioQualityReport{
+		Duration:   a.Duration(),
+		SampleRate: a.SampleRate,
+		Channels:   a.Channels,
+	}
+	if len(a.Samples) == 0 {
+		return report
+	}
+
+	peak, clipped := 0, 0
+	for _, s := range a.Samples {
+		v := int(s)
+		if v < 0 {
+			v = -v
+		}
+		if v > peak {
+			peak = v
+		}
+		if v >= clippingLevel {
+			clipped++
+		}
+	}
+	report.Peak = float64(peak) / 32768
+	report.ClippingRatio = float64(clipped) / float64(len(a.Samples))
+
+	frameLen := a.SampleRate * qualityFrameMs / 1000 * a.Channels
+	if frameLen == 0 {
+		frameLen = a.Channels
+	}
+	var energies []float64
+	silent := 0
+	for start := 0; start < len(a.Samples); start += frameLen {
+		end := min(start+frameLen, len(a.Samples))
+		e := frameEnergyDB(a.Samples[start:end])
+		if e < silenceThresholdDB {
+			silent++
+		}
+		// 数字静音以-120dB计，避免无穷大影响分位数
+		energies = append(energies, math.Max(e, -120))
+	}
+	report.SilenceRatio = float64(silent) / float64(len(energies))
+
+	// 以能量分布的高分位近似语音电平，低分位近似底噪电平
+	sort.Float64s(energies)
+	noise := energies[len(energies)*10/100]
+	signal := energies[min(len(energies)*90/100, len(energies)-1)]
+	report.SNR = signal - noise
+	return report
+}
+
+// AnalyzeTrainingAudio 读取WAV文件并分析质量，8/24/32位整数、浮点和G.711编码先转换为16位PCM，保留原采样率和声道数
+// PCM文件没有文件头，无法判断采样率和声道数，需使用AnalyzeTrainingPCM；其他格式返回错误
+func AnalyzeTrainingAudio(path string) (*AudioQualityReport, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		audio, err := readWAVAsPCM16(path)
+		if err != nil {
+			return nil, err
+		}
+		return AnalyzeAudioQuality(audio), nil
+	case ".pcm":
+		return nil, errors.New("pcm has no header to tell sample rate and channels, use AnalyzeTrainingPCM")
+	}
+	return nil, fmt.Errorf("quality analysis only supports wav/pcm, got %s", filepath.Ext(path))
+}
+
+// AnalyzeTrainingPCM 按format读取无文件头的PCM文件并分析质量
+func AnalyzeTrainingPCM(path string, format PCMFormat) (*AudioQualityReport, error) {
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio file: %v", err)
+	}
+	defer f.Close()
+	audio, err := readAsPCM16(f, format)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzeAudioQuality(audio), nil
+}
+
+// readWAVAsPCM16 读取WAV文件并把采样点转换为16位整数
+func readWAVAsPCM16(path string) (*PCMAudio, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wav file: %v", err)
+	}
+	defer f.Close()
+	format, err := ReadWAVHeader(f)
+	if err != nil {
+		return nil, err
+	}
+	if law, ok := format.g711Law(); ok {
+		in := PCMFormat{SampleRate: format.SampleRate, Channels: format.Channels, Bits: 16}
+		return readAsPCM16(NewG711Decoder(format.wavData(f), law), in)
+	}
+	in, err := format.PCMFormat()
+	if err != nil {
+		return nil, err
+	}
+	return readAsPCM16(format.wavData(f), in)
+}
+
+// readAsPCM16 读出r中in格式的全部采样，转换为同采样率和声道数的16位PCM
+func readAsPCM16(r io.Reader, in PCMFormat) (*PCMAudio, error) {
+	if !in.is16Bit(in.SampleRate, in.Channels) {
+		converter, err := NewPCMConverter(r, in, in.SampleRate, in.Channels)
+		if err != nil {
+			return nil, err
+		}
+		r = converter
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio data: %v", err)
+	}
+	return DecodePCM16(data, in.SampleRate, in.Channels), nil
+}
+
+// Check 按门限检查报告，不满足时返回*QualityError
+func (r *AudioQualityReport) Check(th QualityThresholds) error {
+	var problems []string
+	if th.MinDuration > 0 && r.Duration < th.MinDuration {
+		problems = append(problems, fmt.Sprintf("duration %v shorter than %v", r.Duration, th.MinDuration))
+	}
+	if th.MaxDuration > 0 && r.Duration > th.MaxDuration {
+		problems = append(problems, fmt.Sprintf("duration %v longer than %v", r.Duration, th.MaxDuration))
+	}
+	if th.MinSampleRate > 0 && r.SampleRate < th.MinSampleRate {
+		problems = append(problems, fmt.Sprintf("sample rate %d Hz below %d Hz", r.SampleRate, th.MinSampleRate))
+	}
+	if th.RequireMono && r.Channels != 1 {
+		problems = append(problems, fmt.Sprintf("%d channels, mono required", r.Channels))
+	}
+	if th.MaxClippingRatio > 0 && r.ClippingRatio > th.MaxClippingRatio {
+		problems = append(problems, fmt.Sprintf("clipping ratio %.4f above %.4f", r.ClippingRatio, th.MaxClippingRatio))
+	}
+	if th.MaxSilenceRatio > 0 && r.SilenceRatio > th.MaxSilenceRatio {
+		problems = append(problems, fmt.Sprintf("silence ratio %.2f above %.2f", r.SilenceRatio, th.MaxSilenceRatio))
+	}
+	if th.MinSNR > 0 && r.SNR < th.MinSNR {
+		problems = append(problems, fmt.Sprintf("estimated SNR %.1f dB below %.1f dB", r.SNR, th.MinSNR))
+	}
+
+	if len(problems) > 0 {
+		return &QualityError{Problems: problems}
+	}
+	return nil
+}
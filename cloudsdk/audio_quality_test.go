@@ -0,0 +1,147 @@
+package cloudsdk
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// speechLike 生成0.5秒有声、0.25秒停顿交替的测试音频，并叠加指定幅度的白噪声
+func speechLike(rate int, d time.Duration, noise float64) *PCMAudio {
+	frames := int(d.Seconds() * float64(rate))
+	a := sineTone(rate, frames, 220, 10000)
+	rng := rand.New(rand.NewSource(1))
+	for i := range a.Samples {
+		v := float64(a.Samples[i])
+		if i%(rate*3/4) >= rate/2 {
+			v = 0
+		}
+		a.Samples[i] = clampInt16(v + rng.NormFloat64()*noise)
+	}
+	return a
+}
+
+func TestAnalyzeAudioQuality(t *testing.T) {
+	clean := AnalyzeAudioQuality(speechLike(16000, 12*time.Second, 10))
+	assert.Equal(t, 12*time.Second, clean.Duration)
+	assert.InDelta(t, 10000.0/32768, clean.Peak, 0.01)
+	assert.Zero(t, clean.ClippingRatio)
+	assert.InDelta(t, 1.0/3, clean.SilenceRatio, 0.05)
+	assert.Greater(t, clean.SNR, 40.0)
+	assert.NoError(t, clean.Check(DefaultQualityThresholds()))
+
+	noisy := AnalyzeAudioQuality(speechLike(16000, 12*time.Second, 2000))
+	assert.Less(t, noisy.SNR, 20.0)
+	assert.Error(t, noisy.Check(DefaultQualityThresholds()))
+}
+
+func TestAudioQualityCheck_Problems(t *testing.T) {
+	a := speechLike(8000, 3*time.Second, 10)
+	for i := 0; i < 100; i++ {
+		a.Samples[i] = 32767
+	}
+	stereo := remixChannels(a, 2)
+
+	err := AnalyzeAudioQuality(stereo).Check(DefaultQualityThresholds())
+	var qe *QualityError
+	require.ErrorAs(t, err, &qe)
+	assert.Len(t, qe.Problems, 4) // 时长、采样率、声道、削波
+}
+
+func TestClient_TrainQualityCheck(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "short.wav")
+	require.NoError(t, speechLike(16000, 2*time.Second, 10).WriteWAVFile(path))
+
+	client := NewClient("appid", "token").WithBaseURL(server.URL).WithQualityCheck(DefaultQualityThresholds())
+	_, err := client.Train(context.Background(), "S_test", path)
+	var qe *QualityError
+	require.ErrorAs(t, err, &qe)
+	assert.False(t, called)
+}
+
+func TestAnalyzeTrainingAudio_Formats(t *testing.T) {
+	a := speechLike(16000, 12*time.Second, 10)
+	want := AnalyzeAudioQuality(a)
+
+	// 24位PCM的WAV先转换为16位再分析
+	samples := make([]float64, len(a.Samples))
+	for i, s := range a.Samples {
+		samples[i] = float64(s) / 32768
+	}
+	f := PCMFormat{SampleRate: 16000, Channels: 1, Bits: 24}
+	fmtBody := binary.LittleEndian.AppendUint16(nil, wavFormatPCM)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, 1)
+	fmtBody = binary.LittleEndian.AppendUint32(fmtBody, 16000)
+	fmtBody = binary.LittleEndian.AppendUint32(fmtBody, 16000*3)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, 3)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, 24)
+	body := append([]byte("WAVE"), wavChunk("fmt ", fmtBody)...)
+	body = append(body, wavChunk("data", encodeSamples(samples, f))...)
+	path := filepath.Join(t.TempDir(), "24bit.wav")
+	require.NoError(t, os.WriteFile(path, wavChunk("RIFF", body), 0644))
+
+	report, err := AnalyzeTrainingAudio(path)
+	require.NoError(t, err)
+	assert.Equal(t, want.Duration, report.Duration)
+	assert.Equal(t, 16000, report.SampleRate)
+	assert.InDelta(t, want.Peak, report.Peak, 0.001)
+	assert.InDelta(t, want.SilenceRatio, report.SilenceRatio, 0.01)
+
+	// 无法分析的格式开启质量检查时返回错误，而不是跳过检查
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+	mp3 := filepath.Join(t.TempDir(), "voice.mp3")
+	require.NoError(t, os.WriteFile(mp3, fakeMP3(10), 0644))
+	client := NewClient("appid", "token").WithBaseURL(server.URL).WithQualityCheck(DefaultQualityThresholds())
+	_, err = client.Train(context.Background(), "S_test", mp3)
+	assert.ErrorContains(t, err, "only supports wav/pcm")
+	assert.False(t, called)
+}
+
+func TestClient_TrainQualityCheckPCM(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "voice.pcm")
+	stereo := remixChannels(speechLike(48000, 12*time.Second, 10), 2)
+	require.NoError(t, os.WriteFile(path, stereo.Bytes(), 0644))
+
+	// 没有说明采样格式时无法判断采样率和声道数，返回错误而不是放行
+	client := NewClient("appid", "token").WithBaseURL(server.URL).WithQualityCheck(DefaultQualityThresholds())
+	_, err := client.Train(context.Background(), "S_test", path)
+	assert.ErrorContains(t, err, "AnalyzeTrainingPCM")
+
+	client.WithPCMFormat(PCMFormat{SampleRate: 48000, Channels: 2, Bits: 16})
+	_, err = client.Train(context.Background(), "S_test", path)
+	var qe *QualityError
+	require.ErrorAs(t, err, &qe)
+	assert.Len(t, qe.Problems, 1)
+	assert.Contains(t, qe.Problems[0], "2 channels")
+	assert.False(t, called)
+
+	report, err := AnalyzeTrainingPCM(path, PCMFormat{SampleRate: 48000, Channels: 2, Bits: 16})
+	require.NoError(t, err)
+	assert.Equal(t, 48000, report.SampleRate)
+	assert.Equal(t, 12*time.Second, report.Duration)
+}
@@ -0,0 +1,212 @@
+package cloudsdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// batchConcurrency 批量接口的默认并发数
+const batchConcurrency = 4
+
+// RecognizeRequest 一次语音识别请求，AudioPath和Audio二选一
+// 格式相关的零值字段使用识别器的默认配置
+type RecognizeRequest struct {
+	AudioPath  string
+	Audio      io.Reader
+	Format     string // wav/pcm/mp3/ogg/pcmu/pcma
+	SampleRate int
+	Bits       int
+	Channels   int
+}
+
+// RecognizeResult 与服务商无关的识别结果
+type RecognizeResult struct {
+	Text    string
+	IsFinal bool
+	Raw     interface{} // 服务端原始响应
+}
+
+// Recognizer 语音识别接口，AsrWsClient实现了该接口
+type Recognizer interface {
+	// Recognize 识别整段音频并返回最终结果
+	Recognize(ctx context.Context, req *RecognizeRequest) (*RecognizeResult, error)
+	// RecognizeStreaming 边发送边识别，每收到一个中间结果都会回调onResult
+	RecognizeStreaming(ctx context.Context, req *RecognizeRequest, onResult func(*RecognizeResult)) (*RecognizeResult, error)
+	// RecognizeBatch 批量识别，结果与请求一一对应
+	RecognizeBatch(ctx context.Context, reqs []*RecognizeRequest) ([]*RecognizeResult, error)
+}
+
+// openAudio 以流的形式打开请求中的音频
+func (r *RecognizeRequest) openAudio() (io.ReadCloser, error) {
+	switch {
+	case r.Audio != nil:
+		return io.NopCloser(r.Audio), nil
+	case r.AudioPath != "":
+		f, err := os.Open(r.AudioPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audio file: %v", err)
+		}
+		return f, nil
+	}
+	return nil, errors.New("no audio in request")
+}
+
+// runBatch 以默认并发数执行n个任务，返回第一个错误
+func runBatch(ctx context.Context, n int, task func(ctx context.Context, i int) error) error {
+	return runBatchN(ctx, n, batchConcurrency, task)
+}
+
+// runBatchN 以最多concurrency个并发执行n个任务，返回第一个错误
+func runBatchN(ctx context.Context, n, concurrency int, task func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := task(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("batch item %d: %v", i, err)
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// pollWithBackoff 反复调用fn直到其返回done或错误，两次调用之间的间隔从initial开始逐次翻倍，不超过maxInterval
+// ctx结束时返回ctx.Err()
+func pollWithBackoff(ctx context.Context, initial, maxInterval time.Duration, fn func() (done bool, err error)) error {
+	interval := initial
+	for {
+		done, err := fn()
+		if done || err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		interval = min(2*interval, maxInterval)
+	}
+}
+
+// synthesizeBatch 基于Synthesize实现批量合成
+func synthesizeBatch(ctx context.Context, s Synthesizer, reqs []*SynthRequest) ([]*SynthResult, error) {
+	results := make([]*SynthResult, len(reqs))
+	err := runBatch(ctx, len(reqs), func(ctx context.Context, i int) error {
+		result, err := s.Synthesize(ctx, reqs[i])
+		results[i] = result
+		return err
+	})
+	return results, err
+}
+
+// recognizeBatch 基于Recognize实现批量识别
+func recognizeBatch(ctx context.Context, r Recognizer, reqs []*RecognizeRequest) ([]*RecognizeResult, error) {
+	results := make([]*RecognizeResult, len(reqs))
+	err := runBatch(ctx, len(reqs), func(ctx context.Context, i int) error {
+		result, err := r.Recognize(ctx, reqs[i])
+		results[i] = result
+		return err
+	})
+	return results, err
+}
+
+// withRequest 返回应用了请求级格式参数的客户端副本
+func (c *AsrWsClient) withRequest(req *RecognizeRequest) *AsrWsClient {
+	config := *c.config
+	if req.Format != "" {
+		config.Format = req.Format
+	}
+	if req.SampleRate > 0 {
+		config.Rate = req.SampleRate
+	}
+	if req.Bits > 0 {
+		config.Bits = req.Bits
+	}
+	if req.Channels > 0 {
+		config.Channel = req.Channels
+	}
+	return &AsrWsClient{config: &config}
+}
+
+// Fingerprint 实现RecognizerFingerprint接口，包含影响识别结果的配置，不含鉴权信息
+func (c *AsrWsClient) Fingerprint() string {
+	data, _ := json.Marshal(struct {
+		WsURL                              string
+		Format, Codec                      string
+		Rate, Bits, Channel                int
+		Float                              bool
+		SegDuration, Mp3SegSize            int
+		HotWords                           []string
+		BoostingTableID, BoostingTableName string
+		Context                            []string
+		Options                            AsrRequestOptions
+	}{
+		c.config.WsURL, c.config.Format, c.config.Codec, c.config.Rate, c.config.Bits, c.config.Channel, c.config.Float,
+		c.config.SegDuration, c.config.Mp3SegSize, c.config.hotWords(), c.config.BoostingTableID, c.config.BoostingTableName,
+		c.config.Context, c.config.Options,
+	})
+	return string(data)
+}
+
+// toRecognizeResult 从bigmodel响应中提取识别文本
+func toRecognizeResult(resp *Response) *RecognizeResult {
+	return &RecognizeResult{Text: resp.Result.Text(), IsFinal: resp.IsLastPackage, Raw: resp.PayloadMsg}
+}
+
+// Recognize 实现Recognizer接口
+func (c *AsrWsClient) Recognize(ctx context.Context, req *RecognizeRequest) (*RecognizeResult, error) {
+	return c.RecognizeStreaming(ctx, req, nil)
+}
+
+// RecognizeStreaming 实现Recognizer接口
+func (c *AsrWsClient) RecognizeStreaming(ctx context.Context, req *RecognizeRequest, onResult func(*RecognizeResult)) (*RecognizeResult, error) {
+	audio, err := req.openAudio()
+	if err != nil {
+		return nil, err
+	}
+	defer audio.Close()
+
+	var onResponse func(*Response)
+	if onResult != nil {
+		onResponse = func(resp *Response) { onResult(toRecognizeResult(resp)) }
+	}
+	resp, err := c.withRequest(req).recognizeReader(ctx, audio, onResponse)
+	if err != nil {
+		return nil, err
+	}
+	return toRecognizeResult(resp), nil
+}
+
+// RecognizeBatch 实现Recognizer接口
+func (c *AsrWsClient) RecognizeBatch(ctx context.Context, reqs []*RecognizeRequest) ([]*RecognizeResult, error) {
+	return recognizeBatch(ctx, c, reqs)
+}
@@ -0,0 +1,138 @@
+package cloudsdk
+
+import (
+	"math"
+)
+
+// resampleZeroCrossings 窗函数sinc滤波器单侧的过零点数，越大过渡带越窄
+const resampleZeroCrossings = 12
+
+// Resampler 流式采样率转换器
+// 使用Blackman窗sinc插值，降采样时同时作为抗混叠低通滤波器
+type Resampler struct {
+	inRate   int
+	outRate  int
+	channels int
+	cutoff   float64 // 相对输入奈奎斯特频率的截止频率
+	half     int     // 滤波器单侧宽度(输入采样点)
+
+	buf      []float64 // 交错存储的待处理输入
+	bufStart int64     // buf[0]对应的输入帧序号
+	consumed int64     // 已输入的总帧数
+	produced int64     // 已输出的总帧数
+}
+
+// NewResampler 创建从inRate到outRate的重采样器，输入输出均为交错的16位采样
+func NewResampler(inRate, outRate, channels int) *Resampler {
+	cutoff := 1.0
+	if outRate < inRate {
+		cutoff = float64(outRate) / float64(inRate)
+	}
+	// 略微收窄截止频率，为过渡带留出空间
+	cutoff *= 0.95
+	return &Resampler{
+		inRate:   inRate,
+		outRate:  outRate,
+		channels: channels,
+		cutoff:   cutoff,
+		half:     int(math.Ceil(resampleZeroCrossings / cutoff)),
+	}
+}
+
+// Process 输入一段交错采样，返回当前已能计算出的输出采样
+func (r *Resampler) Process(in []int16) []int16 {
+	if r.inRate == r.outRate {
+		out := make([]int16, len(in))
+		copy(out, in)
+		return out
+	}
+	for _, s := range in {
+		r.buf = append(r.buf, float64(s))
+	}
+	r.consumed += int64(len(in) / r.channels)
+	return r.drain(false)
+}
+
+// Flush 以静音补齐滤波器尾部，输出剩余的全部采样
+func (r *Resampler) Flush() []int16 {
+	if r.inRate == r.outRate {
+		return nil
+	}
+	return r.drain(true)
+}
+
+// drain 计算所有输入已就绪的输出点，final为true时缺失的输入按0处理
+func (r *Resampler) drain(final bool) []int16 {
+	var out []int16
+	available := r.bufStart + int64(len(r.buf)/r.channels)
+	total := r.consumed * int64(r.outRate) / int64(r.inRate)
+	if final && (r.consumed*int64(r.outRate))%int64(r.inRate) != 0 {
+		total++
+	}
+
+	for {
+		// 第produced个输出点在输入时间轴上的位置
+		num := r.produced * int64(r.inRate)
+		center := num / int64(r.outRate)
+		frac := float64(num%int64(r.outRate)) / float64(r.outRate)
+		if final {
+			if r.produced >= total {
+				break
+			}
+		} else if center+int64(r.half) >= available {
+			break
+		}
+
+		for c := 0; c < r.channels; c++ {
+			var acc float64
+			for k := center - int64(r.half) + 1; k <= center+int64(r.half); k++ {
+				if k < r.bufStart || k >= available {
+					continue
+				}
+				x := float64(k-center) - frac
+				acc += r.buf[int(k-r.bufStart)*r.channels+c] * r.kernel(x)
+			}
+			out = append(out, clampInt16(acc))
+		}
+		r.produced++
+	}
+
+	// 丢弃后续输出不再需要的输入
+	next := r.produced * int64(r.inRate) / int64(r.outRate)
+	drop := next - int64(r.half) + 1 - r.bufStart
+	if drop > 0 {
+		if drop > int64(len(r.buf)/r.channels) {
+			drop = int64(len(r.buf) / r.channels)
+		}
+		r.buf = r.buf[int(drop)*r.channels:]
+		r.bufStart += drop
+	}
+	return out
+}
+
+// kernel 计算距离为x(输入采样点)处的滤波器系数
+func (r *Resampler) kernel(x float64) float64 {
+	if math.Abs(x) >= float64(r.half) {
+		return 0
+	}
+	arg := r.cutoff * x
+	sinc := 1.0
+	if arg != 0 {
+		sinc = math.Sin(math.Pi*arg) / (math.Pi * arg)
+	}
+	// Blackman窗
+	n := (x + float64(r.half)) / (2 * float64(r.half))
+	window := 0.42 - 0.5*math.Cos(2*math.Pi*n) + 0.08*math.Cos(4*math.Pi*n)
+	return r.cutoff * sinc * window
+}
+
+// Resample 将整段音频转换为指定采样率
+func Resample(a *PCMAudio, rate int) *PCMAudio {
+	if a.SampleRate == rate {
+		return a
+	}
+	r := NewResampler(a.SampleRate, rate, a.Channels)
+	samples := r.Process(a.Samples)
+	samples = append(samples, r.Flush()...)
+	return &PCMAudio{SampleRate: rate, Channels: a.Channels, Samples: samples}
+}
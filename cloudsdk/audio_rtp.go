@@ -0,0 +1,405 @@
+package cloudsdk
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// RTP静态负载类型中的G.711编码
+const (
+	RTPPayloadPCMU = 0
+	RTPPayloadPCMA = 8
+)
+
+// maxPcapRecord 抓包文件中单条记录的长度上限，超过视为文件损坏
+const maxPcapRecord = 256 << 10
+
+// RTPPacket 解析后的RTP包
+type RTPPacket struct {
+	PayloadType uint8
+	Marker      bool
+	Sequence    uint16
+	Timestamp   uint32
+	SSRC        uint32
+	Payload     []byte
+}
+
+// ParseRTPPacket 解析RTP包，跳过CSRC列表、扩展头和填充
+func ParseRTPPacket(b []byte) (*RTPPacket, error) {
+	if len(b) < 12 {
+		return nil, errors.New("rtp packet too short")
+	}
+	if v := b[0] >> 6; v != 2 {
+		return nil, fmt.Errorf("unsupported rtp version: %d", v)
+	}
+	p := &RTPPacket{
+		PayloadType: b[1] & 0x7f,
+		Marker:      b[1]&0x80 != 0,
+		Sequence:    binary.BigEndian.Uint16(b[2:]),
+		Timestamp:   binary.BigEndian.Uint32(b[4:]),
+		SSRC:        binary.BigEndian.Uint32(b[8:]),
+	}
+
+	offset := 12 + 4*int(b[0]&0x0f)
+	if b[0]&0x10 != 0 {
+		if offset+4 > len(b) {
+			return nil, errors.New("malformed rtp extension header")
+		}
+		offset += 4 + 4*int(binary.BigEndian.Uint16(b[offset+2:]))
+	}
+	end := len(b)
+	if b[0]&0x20 != 0 {
+		end -= int(b[end-1])
+	}
+	if offset > end {
+		return nil, errors.New("malformed rtp packet")
+	}
+	p.Payload = b[offset:end]
+	return p, nil
+}
+
+// g711Law 按负载类型返回压扩律，非G.711负载返回false
+func (p *RTPPacket) g711Law() (G711Law, bool) {
+	switch p.PayloadType {
+	case RTPPayloadPCMU:
+		return MuLaw, true
+	case RTPPayloadPCMA:
+		return ALaw, true
+	}
+	return 0, false
+}
+
+// RTPSource RTP包来源，读完时返回io.EOF
+type RTPSource interface {
+	ReadRTP() (*RTPPacket, error)
+}
+
+// RTPOptions RTP流的重排和丢包处理参数
+type RTPOptions struct {
+	JitterPackets int           // 抖动缓冲的包数，等不到下一个序号时最多再缓存这么多包，默认16
+	SSRC          uint32        // 只接收该SSRC的包，0表示使用收到的第一个G.711包的SSRC
+	MaxGap        time.Duration // 时间戳缺口不超过该时长时补静音，更大的跳变视为不连续，默认1s
+}
+
+func (o RTPOptions) withDefaults() RTPOptions {
+	if o.JitterPackets <= 0 {
+		o.JitterPackets = 16
+	}
+	if o.MaxGap <= 0 {
+		o.MaxGap = time.Second
+	}
+	return o
+}
+
+// RTPStream 将RTP包按序号重排后解码为8kHz单声道16位小端PCM
+// 超出抖动缓冲才到达的包被丢弃，丢包和静音抑制造成的时间戳缺口以静音补齐
+type RTPStream struct {
+	Lost int // 未收到而被跳过的包数
+	Late int // 迟到或重复而被丢弃的包数
+
+	src     RTPSource
+	opts    RTPOptions
+	ssrc    uint32
+	started bool
+	emitted bool
+	next    uint16 // 下一个要输出的序号
+	nextTS  uint32 // 下一个包预期的时间戳
+	pending map[uint16]*RTPPacket
+	out     []byte
+	err     error
+}
+
+// NewRTPStream 创建从src读取G.711 RTP包的PCM流
+func NewRTPStream(src RTPSource, opts RTPOptions) *RTPStream {
+	opts = opts.withDefaults()
+	return &RTPStream{src: src, opts: opts, ssrc: opts.SSRC, pending: make(map[uint16]*RTPPacket)}
+}
+
+// Read 实现io.Reader接口
+func (s *RTPStream) Read(p []byte) (int, error) {
+	for len(s.out) == 0 {
+		if !s.fill() {
+			return 0, s.err
+		}
+	}
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+	return n, nil
+}
+
+// fill 输出下一个包，缓冲为空且来源已结束时返回false
+func (s *RTPStream) fill() bool {
+	for s.err == nil && len(s.pending) < s.opts.JitterPackets {
+		if _, ok := s.pending[s.next]; ok && s.started {
+			break
+		}
+		pkt, err := s.src.ReadRTP()
+		if err != nil {
+			if err != io.EOF {
+				err = fmt.Errorf("failed to read rtp: %v", err)
+			}
+			s.err = err
+			break
+		}
+		s.push(pkt)
+	}
+	if len(s.pending) == 0 {
+		return false
+	}
+
+	pkt, ok := s.pending[s.next]
+	if !ok {
+		// 抖动缓冲已满或来源已结束，跳过未到达的包
+		pkt = s.earliest()
+		s.Lost += int(pkt.Sequence - s.next)
+	}
+	delete(s.pending, pkt.Sequence)
+	s.emit(pkt)
+	return true
+}
+
+// push 将包放入抖动缓冲
+func (s *RTPStream) push(pkt *RTPPacket) {
+	if _, ok := pkt.g711Law(); !ok {
+		return // 舒适噪声、DTMF等负载
+	}
+	if !s.started && s.ssrc == 0 {
+		s.ssrc = pkt.SSRC
+	}
+	if pkt.SSRC != s.ssrc {
+		return
+	}
+
+	before := int16(pkt.Sequence-s.next) < 0
+	switch {
+	case !s.started || (!s.emitted && before):
+		// 首个包之前的包在开始输出前到达，起点前移
+		s.started = true
+		s.next, s.nextTS = pkt.Sequence, pkt.Timestamp
+	case before:
+		s.Late++
+		return
+	}
+	if _, ok := s.pending[pkt.Sequence]; ok {
+		s.Late++
+		return
+	}
+	s.pending[pkt.Sequence] = pkt
+}
+
+// earliest 返回缓冲中序号最靠前的包
+func (s *RTPStream) earliest() *RTPPacket {
+	var first *RTPPacket
+	for seq, pkt := range s.pending {
+		if first == nil || seq-s.next < first.Sequence-s.next {
+			first = pkt
+		}
+	}
+	return first
+}
+
+// emit 解码一个包，必要时先补静音
+func (s *RTPStream) emit(pkt *RTPPacket) {
+	if s.emitted {
+		gap := int32(pkt.Timestamp - s.nextTS)
+		if gap > 0 && time.Duration(gap)*time.Second/G711SampleRate <= s.opts.MaxGap {
+			s.out = append(s.out, make([]byte, 2*gap)...)
+		}
+	}
+	law, _ := pkt.g711Law()
+	for _, b := range pkt.Payload {
+		v := muLawToLinear(b)
+		if law == ALaw {
+			v = aLawToLinear(b)
+		}
+		s.out = binary.LittleEndian.AppendUint16(s.out, uint16(v))
+	}
+	s.emitted = true
+	s.next = pkt.Sequence + 1
+	s.nextTS = pkt.Timestamp + uint32(len(pkt.Payload))
+}
+
+// UDPRTPSource 从本地UDP端口接收RTP包
+type UDPRTPSource struct {
+	IdleTimeout time.Duration // 超过该时长没有收到包视为流结束，0表示一直等待
+
+	conn net.PacketConn
+	buf  []byte
+}
+
+// ListenRTP 在addr上监听RTP包，如":5004"
+func ListenRTP(addr string) (*UDPRTPSource, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen rtp failed: %v", err)
+	}
+	return &UDPRTPSource{conn: conn, buf: make([]byte, 65536)}, nil
+}
+
+// Addr 返回实际监听的地址
+func (u *UDPRTPSource) Addr() net.Addr {
+	return u.conn.LocalAddr()
+}
+
+// ReadRTP 实现RTPSource接口，忽略无法解析的包，超时或关闭后返回io.EOF
+func (u *UDPRTPSource) ReadRTP() (*RTPPacket, error) {
+	for {
+		if u.IdleTimeout > 0 {
+			u.conn.SetReadDeadline(time.Now().Add(u.IdleTimeout))
+		}
+		n, _, err := u.conn.ReadFrom(u.buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.Is(err, net.ErrClosed) || (errors.As(err, &netErr) && netErr.Timeout()) {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		// 缓冲区会被复用，抖动缓冲中的包需要独立的副本
+		if pkt, err := ParseRTPPacket(append([]byte(nil), u.buf[:n]...)); err == nil {
+			return pkt, nil
+		}
+	}
+}
+
+// Close 停止监听，阻塞中的ReadRTP返回io.EOF
+func (u *UDPRTPSource) Close() error {
+	return u.conn.Close()
+}
+
+// pcap链路层类型
+const (
+	pcapLinkNull     = 0
+	pcapLinkEthernet = 1
+	pcapLinkRaw      = 101
+	pcapLinkLinuxSLL = 113
+)
+
+// PcapRTPSource 从libpcap格式的抓包文件中读取UDP承载的RTP包
+// 支持以太网、Linux cooked、loopback和裸IP链路，IPv4分片包会被跳过
+type PcapRTPSource struct {
+	Port int // 只读取目的端口为Port的UDP包，0表示不过滤
+
+	r        io.Reader
+	order    binary.ByteOrder
+	linkType uint32
+}
+
+// NewPcapRTPSource 读取抓包文件头并创建RTP来源
+func NewPcapRTPSource(r io.Reader) (*PcapRTPSource, error) {
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read pcap header: %v", err)
+	}
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(header) {
+	case 0xa1b2c3d4, 0xa1b23c4d:
+		order = binary.LittleEndian
+	case 0xd4c3b2a1, 0x4d3cb2a1:
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("not a pcap file")
+	}
+	return &PcapRTPSource{r: r, order: order, linkType: order.Uint32(header[20:])}, nil
+}
+
+// ReadRTP 实现RTPSource接口，跳过非UDP包和无法解析为RTP的UDP包
+func (p *PcapRTPSource) ReadRTP() (*RTPPacket, error) {
+	record := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(p.r, record); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return nil, errors.New("truncated pcap record header")
+			}
+			return nil, err
+		}
+		size := p.order.Uint32(record[8:])
+		if size > maxPcapRecord {
+			return nil, fmt.Errorf("invalid pcap record length: %d", size)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(p.r, data); err != nil {
+			return nil, errors.New("truncated pcap record")
+		}
+
+		payload, port, ok := p.udpPayload(data)
+		if !ok || (p.Port > 0 && port != p.Port) {
+			continue
+		}
+		if pkt, err := ParseRTPPacket(payload); err == nil {
+			return pkt, nil
+		}
+	}
+}
+
+// udpPayload 从链路层帧中取出UDP负载和目的端口
+func (p *PcapRTPSource) udpPayload(frame []byte) ([]byte, int, bool) {
+	var ip []byte
+	switch p.linkType {
+	case pcapLinkEthernet:
+		if len(frame) < 14 {
+			return nil, 0, false
+		}
+		etherType, offset := binary.BigEndian.Uint16(frame[12:]), 14
+		if etherType == 0x8100 && len(frame) >= 18 { // 802.1Q VLAN
+			etherType, offset = binary.BigEndian.Uint16(frame[16:]), 18
+		}
+		if etherType != 0x0800 && etherType != 0x86dd {
+			return nil, 0, false
+		}
+		ip = frame[offset:]
+	case pcapLinkLinuxSLL:
+		if len(frame) < 16 {
+			return nil, 0, false
+		}
+		ip = frame[16:]
+	case pcapLinkNull:
+		if len(frame) < 4 {
+			return nil, 0, false
+		}
+		ip = frame[4:]
+	case pcapLinkRaw:
+		ip = frame
+	default:
+		return nil, 0, false
+	}
+
+	var udp []byte
+	switch {
+	case len(ip) >= 20 && ip[0]>>4 == 4:
+		ihl := 4 * int(ip[0]&0x0f)
+		total := int(binary.BigEndian.Uint16(ip[2:]))
+		if ip[9] != 17 || binary.BigEndian.Uint16(ip[6:])&0x3fff != 0 || ihl < 20 || total < ihl || total > len(ip) {
+			return nil, 0, false
+		}
+		udp = ip[ihl:total]
+	case len(ip) >= 40 && ip[0]>>4 == 6:
+		total := 40 + int(binary.BigEndian.Uint16(ip[4:]))
+		if ip[6] != 17 || total > len(ip) {
+			return nil, 0, false
+		}
+		udp = ip[40:total]
+	default:
+		return nil, 0, false
+	}
+
+	if len(udp) < 8 {
+		return nil, 0, false
+	}
+	length := int(binary.BigEndian.Uint16(udp[4:]))
+	if length < 8 || length > len(udp) {
+		return nil, 0, false
+	}
+	return udp[8:length], int(binary.BigEndian.Uint16(udp[2:])), true
+}
+
+// RecognizeRTP 识别RTP流中的G.711语音，src读完时结束
+// 从UDP接收时ctx取消不会中断阻塞的读取，调用方应同时关闭src或设置IdleTimeout
+func (c *AsrWsClient) RecognizeRTP(ctx context.Context, src RTPSource, opts RTPOptions) (*Response, error) {
+	return c.withPCMInput(G711SampleRate, 1).recognizeReader(ctx, NewRTPStream(src, opts), nil)
+}
@@ -0,0 +1,273 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rtpPacket 构造RTP包，负载为160字节的G.711数据
+func rtpPacket(seq uint16, ts uint32, pt uint8, fill byte) []byte {
+	b := []byte{0x80, pt}
+	b = binary.BigEndian.AppendUint16(b, seq)
+	b = binary.BigEndian.AppendUint32(b, ts)
+	b = binary.BigEndian.AppendUint32(b, 0xcafe)
+	return append(b, bytes.Repeat([]byte{fill}, 160)...)
+}
+
+// sliceRTPSource 按给定顺序返回包
+type sliceRTPSource [][]byte
+
+func (s *sliceRTPSource) ReadRTP() (*RTPPacket, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+	b := (*s)[0]
+	*s = (*s)[1:]
+	return ParseRTPPacket(b)
+}
+
+// decodedFill 返回n个G.711字节fill解码后的PCM
+func decodedFill(fill byte, n int, law G711Law) []byte {
+	return DecodeG711(bytes.Repeat([]byte{fill}, n), law).Bytes()
+}
+
+func TestParseRTPPacket(t *testing.T) {
+	b := []byte{0xb2, 0x88} // 带填充、扩展头，2个CSRC，marker，PCMA
+	b = binary.BigEndian.AppendUint16(b, 7)
+	b = binary.BigEndian.AppendUint32(b, 1000)
+	b = binary.BigEndian.AppendUint32(b, 42)
+	b = append(b, make([]byte, 8)...)                       // CSRC
+	b = append(b, 0xbe, 0xde, 0x00, 0x01, 1, 2, 3, 4)       // 扩展头
+	b = append(b, 0x11, 0x22, 0x33, 0x00, 0x00, 0x00, 0x03) // 负载+3字节填充
+
+	p, err := ParseRTPPacket(b)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(RTPPayloadPCMA), p.PayloadType)
+	assert.True(t, p.Marker)
+	assert.Equal(t, uint16(7), p.Sequence)
+	assert.Equal(t, uint32(1000), p.Timestamp)
+	assert.Equal(t, uint32(42), p.SSRC)
+	assert.Equal(t, []byte{0x11, 0x22, 0x33, 0x00}, p.Payload)
+
+	_, err = ParseRTPPacket(b[:10])
+	assert.Error(t, err)
+	_, err = ParseRTPPacket(append([]byte{0x40}, b[1:]...))
+	assert.ErrorContains(t, err, "version")
+}
+
+func TestRTPStream_Reorder(t *testing.T) {
+	// 序号跨越65535回绕，乱序、重复、丢失和非G.711负载各一个
+	src := sliceRTPSource{
+		rtpPacket(65535, 0, RTPPayloadPCMU, 1),
+		rtpPacket(1, 320, RTPPayloadPCMU, 3),
+		rtpPacket(0, 160, RTPPayloadPCMU, 2),
+		rtpPacket(1, 320, RTPPayloadPCMU, 3),
+		rtpPacket(2, 480, 101, 0), // DTMF
+		rtpPacket(3, 640, RTPPayloadPCMU, 5),
+	}
+	stream := NewRTPStream(&src, RTPOptions{})
+	got, err := io.ReadAll(stream)
+	require.NoError(t, err)
+
+	var want []byte
+	for _, fill := range []byte{1, 2, 3} {
+		want = append(want, decodedFill(fill, 160, MuLaw)...)
+	}
+	want = append(want, make([]byte, 2*160)...) // 序号2丢失，补160个采样的静音
+	want = append(want, decodedFill(5, 160, MuLaw)...)
+	assert.Equal(t, want, got)
+	assert.Equal(t, 1, stream.Lost)
+	assert.Equal(t, 1, stream.Late)
+}
+
+func TestRTPStream_JitterBuffer(t *testing.T) {
+	// 序号1在缓冲满之后才到达，已被跳过
+	src := sliceRTPSource{
+		rtpPacket(0, 0, RTPPayloadPCMA, 1),
+		rtpPacket(2, 320, RTPPayloadPCMA, 3),
+		rtpPacket(3, 480, RTPPayloadPCMA, 4),
+		rtpPacket(1, 160, RTPPayloadPCMA, 2),
+		rtpPacket(4, 640, RTPPayloadPCMA, 5),
+		rtpPacket(9, 8000*10, RTPPayloadPCMA, 6), // 时间戳跳变超过MaxGap，不补静音
+	}
+	stream := NewRTPStream(&src, RTPOptions{JitterPackets: 2})
+	got, err := io.ReadAll(stream)
+	require.NoError(t, err)
+
+	var want []byte
+	want = append(want, decodedFill(1, 160, ALaw)...)
+	want = append(want, make([]byte, 2*160)...)
+	for _, fill := range []byte{3, 4, 5, 6} {
+		want = append(want, decodedFill(fill, 160, ALaw)...)
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, 5, stream.Lost)
+	assert.Equal(t, 1, stream.Late)
+}
+
+// pcapFile 构造以太网链路的抓包文件，每个负载封装为发往port的IPv4/UDP包
+func pcapFile(port uint16, payloads ...[]byte) []byte {
+	var b []byte
+	b = binary.LittleEndian.AppendUint32(b, 0xa1b2c3d4)
+	b = binary.LittleEndian.AppendUint16(b, 2)
+	b = binary.LittleEndian.AppendUint16(b, 4)
+	b = append(b, make([]byte, 8)...)
+	b = binary.LittleEndian.AppendUint32(b, 65535)
+	b = binary.LittleEndian.AppendUint32(b, pcapLinkEthernet)
+
+	for _, payload := range payloads {
+		frame := append(make([]byte, 12), 0x08, 0x00)
+		ip := []byte{0x45, 0}
+		ip = binary.BigEndian.AppendUint16(ip, uint16(20+8+len(payload)))
+		ip = append(ip, 0, 0, 0x40, 0, 64, 17, 0, 0, 127, 0, 0, 1, 127, 0, 0, 1)
+		udp := binary.BigEndian.AppendUint16(nil, 40000)
+		udp = binary.BigEndian.AppendUint16(udp, port)
+		udp = binary.BigEndian.AppendUint16(udp, uint16(8+len(payload)))
+		udp = append(udp, 0, 0)
+		frame = append(append(append(frame, ip...), udp...), payload...)
+		frame = append(frame, 0, 0, 0, 0) // 以太网尾部填充
+
+		b = append(b, make([]byte, 8)...)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(frame)))
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(frame)))
+		b = append(b, frame...)
+	}
+	return b
+}
+
+func TestPcapRTPSource(t *testing.T) {
+	data := pcapFile(5004,
+		rtpPacket(10, 0, RTPPayloadPCMU, 1),
+		[]byte("INVITE sip:bob@example.com SIP/2.0"),
+		rtpPacket(11, 160, RTPPayloadPCMU, 2),
+	)
+	src, err := NewPcapRTPSource(bytes.NewReader(data))
+	require.NoError(t, err)
+	src.Port = 5004
+
+	var seqs []uint16
+	for {
+		p, err := src.ReadRTP()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Len(t, p.Payload, 160)
+		seqs = append(seqs, p.Sequence)
+	}
+	assert.Equal(t, []uint16{10, 11}, seqs)
+
+	src, err = NewPcapRTPSource(bytes.NewReader(data))
+	require.NoError(t, err)
+	src.Port = 5006
+	_, err = src.ReadRTP()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = NewPcapRTPSource(bytes.NewReader(make([]byte, 24)))
+	assert.Error(t, err)
+}
+
+func TestUDPRTPSource(t *testing.T) {
+	src, err := ListenRTP("127.0.0.1:0")
+	require.NoError(t, err)
+	defer src.Close()
+	src.IdleTimeout = 200 * time.Millisecond
+
+	conn, err := net.Dial("udp", src.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	for i := 0; i < 5; i++ {
+		_, err := conn.Write(rtpPacket(uint16(i), uint32(160*i), RTPPayloadPCMU, byte(i)))
+		require.NoError(t, err)
+	}
+
+	got, err := io.ReadAll(NewRTPStream(src, RTPOptions{}))
+	require.NoError(t, err)
+	assert.Len(t, got, 5*160*2)
+	assert.Equal(t, decodedFill(4, 160, MuLaw), got[4*320:])
+}
+
+func TestAsrWsClient_RecognizeRTP(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "pcm")
+
+	var src sliceRTPSource
+	for i := 0; i < 50; i++ {
+		src = append(src, rtpPacket(uint16(i), uint32(160*i), RTPPayloadPCMU, 0x7e))
+	}
+	_, err := client.RecognizeRTP(context.Background(), &src, RTPOptions{})
+	require.NoError(t, err)
+
+	// 1秒8kHz音频上采样到16kHz后发送
+	assert.InDelta(t, 32000, len(server.Audio()), 64)
+	audio := server.request["audio"].(map[string]interface{})
+	assert.Equal(t, "pcm", audio["format"])
+	assert.Equal(t, float64(16000), audio["sample_rate"])
+}
+
+func TestAsrWsClient_G711Format(t *testing.T) {
+	server := newFakeAsrServer(t)
+	client := newTestAsrClient(server, "pcma")
+
+	encoded := EncodeG711(sineTone(8000, 8000, 440, 12000), ALaw)
+	_, err := client.RecognizeReader(context.Background(), bytes.NewReader(encoded))
+	require.NoError(t, err)
+
+	assert.InDelta(t, 32000, len(server.Audio()), 64)
+	audio := server.request["audio"].(map[string]interface{})
+	assert.Equal(t, "pcm", audio["format"])
+	assert.Equal(t, float64(16000), audio["sample_rate"])
+}
+
+// interleaveG711 将两路G.711数据交织为双声道
+func interleaveG711(left, right []byte) []byte {
+	out := make([]byte, 0, 2*len(left))
+	for i := range left {
+		out = append(out, left[i], right[i])
+	}
+	return out
+}
+
+// g711WAV 构造G.711编码的WAV文件
+func g711WAV(data []byte, tag uint16, channels int) []byte {
+	fmtBody := binary.LittleEndian.AppendUint16(nil, tag)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, uint16(channels))
+	fmtBody = binary.LittleEndian.AppendUint32(fmtBody, G711SampleRate)
+	fmtBody = binary.LittleEndian.AppendUint32(fmtBody, uint32(G711SampleRate*channels))
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, uint16(channels))
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, 8)
+	body := append([]byte("WAVE"), wavChunk("fmt ", fmtBody)...)
+	body = append(body, wavChunk("data", data)...)
+	return wavChunk("RIFF", body)
+}
+
+func TestAsrWsClient_G711Stereo(t *testing.T) {
+	tone := EncodeG711(sineTone(8000, 8000, 440, 12000), MuLaw)
+	silence := bytes.Repeat([]byte{0xff}, len(tone))
+	stereo := interleaveG711(tone, silence)
+
+	for name, input := range map[string][]byte{
+		"pcmu": stereo,
+		"wav":  g711WAV(stereo, wavFormatMuLaw, 2),
+	} {
+		server := newFakeAsrServer(t)
+		client := newTestAsrClient(server, name)
+		client.config.Channel = 2
+		_, err := client.RecognizeReader(context.Background(), bytes.NewReader(input))
+		require.NoError(t, err, name)
+
+		// 1秒双声道混为单声道并上采样到16kHz，而不是按单声道解码成2秒
+		assert.InDelta(t, 32000, len(server.Audio()), 64, name)
+		audio := server.request["audio"].(map[string]interface{})
+		assert.Equal(t, float64(1), audio["channel"], name)
+		assert.Equal(t, float64(16000), audio["sample_rate"], name)
+	}
+}
@@ -0,0 +1,260 @@
+package cloudsdk
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SubtitleOptions 字幕切分参数
+type SubtitleOptions struct {
+	MaxLineLength     int           // 每行最多字符数，默认 20
+	MaxLines          int           // 每条字幕最多行数，默认 2
+	MaxCharsPerSecond float64       // 阅读速度上限，超出时在不与下一条重叠的前提下延长显示时间，默认 10
+	MinDuration       time.Duration // 每条字幕的最短显示时间，默认 1s
+}
+
+func (o *SubtitleOptions) withDefaults() SubtitleOptions {
+	opts := *o
+	if opts.MaxLineLength <= 0 {
+		opts.MaxLineLength = 20
+	}
+	if opts.MaxLines <= 0 {
+		opts.MaxLines = 2
+	}
+	if opts.MaxCharsPerSecond <= 0 {
+		opts.MaxCharsPerSecond = 10
+	}
+	if opts.MinDuration <= 0 {
+		opts.MinDuration = time.Second
+	}
+	return opts
+}
+
+// SubtitleCue 一条字幕
+type SubtitleCue struct {
+	Start time.Duration
+	End   time.Duration
+	Lines []string
+}
+
+// isBreakPunct 可以在其后换行的标点
+func isBreakPunct(r rune) bool {
+	return strings.ContainsRune("，。！？；：、,.!?;:", r)
+}
+
+// splitPhrases 在标点和空格处把文本切成短语，标点留在前一个短语末尾
+func splitPhrases(text string) []string {
+	var phrases []string
+	var cur strings.Builder
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			if cur.Len() > 0 {
+				cur.WriteRune(' ')
+			}
+			continue
+		}
+		cur.WriteRune(r)
+		if isBreakPunct(r) {
+			phrases = append(phrases, cur.String())
+			cur.Reset()
+		}
+	}
+	if cur.Len() > 0 {
+		phrases = append(phrases, cur.String())
+	}
+	return phrases
+}
+
+// wrapLines 将短语拼成不超过maxLen个字符的行，优先在标点处换行，过长的短语在空格处或强制切开
+func wrapLines(text string, maxLen int) []string {
+	var lines []string
+	var line string
+	flush := func() {
+		if s := strings.TrimSpace(line); s != "" {
+			lines = append(lines, s)
+		}
+		line = ""
+	}
+	for _, phrase := range splitPhrases(text) {
+		if utf8.RuneCountInString(strings.TrimRight(line+phrase, " ")) <= maxLen {
+			line += phrase
+			continue
+		}
+		flush()
+		for _, word := range strings.SplitAfter(phrase, " ") {
+			if utf8.RuneCountInString(strings.TrimRight(line+word, " ")) > maxLen {
+				flush()
+			}
+			for utf8.RuneCountInString(word) > maxLen {
+				runes := []rune(word)
+				lines = append(lines, string(runes[:maxLen]))
+				word = string(runes[maxLen:])
+			}
+			line += word
+		}
+	}
+	flush()
+	return lines
+}
+
+// runeTimer 估算文本中第n个非标点字符的时间
+type runeTimer struct {
+	times    []WordTiming // 按字符展开的时间，与文本对齐失败时为空
+	start    time.Duration
+	duration time.Duration
+	total    int // 非标点字符总数
+}
+
+func countSpoken(s string) int {
+	n := 0
+	for _, r := range s {
+		if !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+			n++
+		}
+	}
+	return n
+}
+
+func newRuneTimer(u AsrUtterance) *runeTimer {
+	t := &runeTimer{start: u.Start(), duration: u.End() - u.Start(), total: countSpoken(u.Text)}
+	for _, w := range u.Words {
+		for range countSpoken(w.Text) {
+			t.times = append(t.times, WordTiming{Start: w.Start(), End: w.End()})
+		}
+	}
+	// 字级别时间戳与文本不一致时按字数均分
+	if len(t.times) != t.total {
+		t.times = nil
+	}
+	return t
+}
+
+// span 返回第from到第to个(不含)非标点字符覆盖的时间段
+func (t *runeTimer) span(from, to int) (time.Duration, time.Duration) {
+	if to <= from || t.total == 0 {
+		at := t.start + t.duration*time.Duration(from)/time.Duration(max(t.total, 1))
+		return at, at
+	}
+	if t.times != nil {
+		return t.times[from].Start, t.times[to-1].End
+	}
+	per := func(n int) time.Duration { return t.start + t.duration*time.Duration(n)/time.Duration(t.total) }
+	return per(from), per(to)
+}
+
+// BuildSubtitleCues 按行长、行数切分分句并计算每条字幕的时间，有字级别时间戳时按字对齐
+func BuildSubtitleCues(utterances []AsrUtterance, opts SubtitleOptions) []SubtitleCue {
+	o := opts.withDefaults()
+	var cues []SubtitleCue
+	for _, u := range utterances {
+		timer := newRuneTimer(u)
+		lines := wrapLines(u.Text, o.MaxLineLength)
+		spoken := 0
+		for i := 0; i < len(lines); i += o.MaxLines {
+			group := lines[i:min(i+o.MaxLines, len(lines))]
+			n := countSpoken(strings.Join(group, ""))
+			start, end := timer.span(spoken, spoken+n)
+			spoken += n
+			cues = append(cues, SubtitleCue{Start: start, End: end, Lines: group})
+		}
+	}
+
+	// 阅读速度和最短时长：延长显示时间但不与下一条重叠
+	for i := range cues {
+		c := &cues[i]
+		chars := utf8.RuneCountInString(strings.Join(c.Lines, ""))
+		want := max(o.MinDuration, time.Duration(float64(chars)/o.MaxCharsPerSecond*float64(time.Second)))
+		if c.End-c.Start < want {
+			c.End = c.Start + want
+		}
+		if i+1 < len(cues) && c.End > cues[i+1].Start {
+			c.End = max(cues[i+1].Start, c.Start)
+		}
+	}
+	return cues
+}
+
+// formatTimestamp 格式化为hh:mm:ss加毫秒，sep为毫秒前的分隔符
+func formatTimestamp(d time.Duration, sep string) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// WriteSRT 将字幕写为SRT格式
+func WriteSRT(w io.Writer, cues []SubtitleCue) error {
+	for i, c := range cues {
+		_, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1,
+			formatTimestamp(c.Start, ","), formatTimestamp(c.End, ","), strings.Join(c.Lines, "\n"))
+		if err != nil {
+			return fmt.Errorf("write srt failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// WriteWebVTT 将字幕写为WebVTT格式
+func WriteWebVTT(w io.Writer, cues []SubtitleCue) error {
+	if _, err := io.WriteString(w, "WEBVTT\n\n"); err != nil {
+		return fmt.Errorf("write webvtt failed: %v", err)
+	}
+	for _, c := range cues {
+		_, err := fmt.Fprintf(w, "%s --> %s\n%s\n\n",
+			formatTimestamp(c.Start, "."), formatTimestamp(c.End, "."), strings.Join(c.Lines, "\n"))
+		if err != nil {
+			return fmt.Errorf("write webvtt failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// FormatSRT 将识别结果转换为SRT字幕
+func FormatSRT(result *AsrResult, opts SubtitleOptions) string {
+	var b strings.Builder
+	WriteSRT(&b, BuildSubtitleCues(result.Utterances(), opts))
+	return b.String()
+}
+
+// FormatWebVTT 将识别结果转换为WebVTT字幕
+func FormatWebVTT(result *AsrResult, opts SubtitleOptions) string {
+	var b strings.Builder
+	WriteWebVTT(&b, BuildSubtitleCues(result.Utterances(), opts))
+	return b.String()
+}
+
+// FormatTranscript 将分句拼接为纯文本，相邻分句间隔不小于paragraphGap时另起一段，默认 2s
+func FormatTranscript(result *AsrResult, paragraphGap time.Duration) string {
+	if paragraphGap <= 0 {
+		paragraphGap = 2 * time.Second
+	}
+	utterances := result.Utterances()
+	if len(utterances) == 0 {
+		return result.Text()
+	}
+
+	var b strings.Builder
+	for i, u := range utterances {
+		text := strings.TrimSpace(u.Text)
+		if i > 0 {
+			prev := utterances[i-1]
+			switch {
+			case u.Start()-prev.End() >= paragraphGap:
+				b.WriteString("\n\n")
+			case needsSpace(prev.Text, text):
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// needsSpace 两段拉丁文字相接时需要空格分隔，中文则直接拼接
+func needsSpace(prev, next string) bool {
+	last, _ := utf8.DecodeLastRuneInString(strings.TrimSpace(prev))
+	first, _ := utf8.DecodeRuneInString(next)
+	return last < utf8.RuneSelf && last != utf8.RuneError && first < utf8.RuneSelf && first != utf8.RuneError
+}
@@ -0,0 +1,71 @@
+package cloudsdk
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapLines(t *testing.T) {
+	assert.Equal(t, []string{"很久以前，在一个小村庄里，", "住着一位年轻的母亲。"},
+		wrapLines("很久以前，在一个小村庄里，住着一位年轻的母亲。", 14))
+	assert.Equal(t, []string{"the quick brown", "fox jumps over", "the lazy dog"},
+		wrapLines("the quick brown fox jumps over the lazy dog", 15))
+	assert.Equal(t, []string{"一二三四五", "六七"}, wrapLines("一二三四五六七", 5))
+}
+
+func TestBuildSubtitleCues(t *testing.T) {
+	words := func(text string, start, per int) []AsrWord {
+		var out []AsrWord
+		for i, r := range []rune(text) {
+			out = append(out, AsrWord{Text: string(r), StartTime: start + i*per, EndTime: start + (i+1)*per})
+		}
+		return out
+	}
+	utterances := []AsrUtterance{
+		{Text: "大家好，我给大家讲一个故事。", StartTime: 0, EndTime: 3000, Words: words("大家好我给大家讲一个故事", 0, 250)},
+		{Text: "好。", StartTime: 3100, EndTime: 3300},
+		{Text: "很久以前。", StartTime: 6000, EndTime: 7000},
+	}
+	cues := BuildSubtitleCues(utterances, SubtitleOptions{MaxLineLength: 8, MaxLines: 1})
+
+	assert.Equal(t, []SubtitleCue{
+		// 按字级别时间戳对齐
+		{Start: 0, End: 750 * time.Millisecond, Lines: []string{"大家好，"}},
+		{Start: 750 * time.Millisecond, End: 2750 * time.Millisecond, Lines: []string{"我给大家讲一个故"}},
+		{Start: 2750 * time.Millisecond, End: 3100 * time.Millisecond, Lines: []string{"事。"}},
+		// 最短显示1s
+		{Start: 3100 * time.Millisecond, End: 4100 * time.Millisecond, Lines: []string{"好。"}},
+		{Start: 6000 * time.Millisecond, End: 7000 * time.Millisecond, Lines: []string{"很久以前。"}},
+	}, cues)
+}
+
+func TestFormatSubtitles(t *testing.T) {
+	result := &AsrResult{}
+	result.Result.Utterances = []AsrUtterance{
+		{Text: "你好。", StartTime: 1500, EndTime: 2500},
+		{Text: "再见。", StartTime: 3723004, EndTime: 3724004},
+	}
+
+	assert.Equal(t, "1\n00:00:01,500 --> 00:00:02,500\n你好。\n\n"+
+		"2\n01:02:03,004 --> 01:02:04,004\n再见。\n\n", FormatSRT(result, SubtitleOptions{}))
+	assert.True(t, strings.HasPrefix(FormatWebVTT(result, SubtitleOptions{}),
+		"WEBVTT\n\n00:00:01.500 --> 00:00:02.500\n你好。\n\n"))
+}
+
+func TestFormatTranscript(t *testing.T) {
+	result := &AsrResult{}
+	result.Result.Utterances = []AsrUtterance{
+		{Text: "你好。", StartTime: 0, EndTime: 1000},
+		{Text: "今天天气不错。", StartTime: 1200, EndTime: 3000},
+		{Text: "Hello", StartTime: 6000, EndTime: 6500},
+		{Text: "world.", StartTime: 6600, EndTime: 7000},
+	}
+	assert.Equal(t, "你好。今天天气不错。\n\nHello world.", FormatTranscript(result, 0))
+
+	empty := &AsrResult{}
+	empty.Result.Text = "只有整段文本"
+	assert.Equal(t, "只有整段文本", FormatTranscript(empty, 0))
+}
@@ -0,0 +1,237 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultTTSSampleRate 服务端未指定采样率时的默认值
+const defaultTTSSampleRate = 24000
+
+// SynthRequest 一次语音合成请求，零值字段使用合成器的默认配置
+type SynthRequest struct {
+	Text       string
+	VoiceType  string
+	Encoding   string // mp3/pcm/wav
+	SampleRate int
+	SpeedRatio float32
+}
+
+// WordTiming 单个字或词在音频中的起止时间
+type WordTiming struct {
+	Word  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// SynthResult 语音合成结果
+type SynthResult struct {
+	Audio      []byte
+	Encoding   string
+	SampleRate int
+	Duration   time.Duration
+	Words      []WordTiming
+}
+
+// Synthesizer 语音合成接口，TTSWsClient和DryRunSynthesizer都实现了该接口
+type Synthesizer interface {
+	// Synthesize 一次性合成整段音频，并尽可能返回字级别时间戳
+	Synthesize(ctx context.Context, req *SynthRequest) (*SynthResult, error)
+	// SynthesizeStream 流式合成，每收到一段音频就写入w
+	SynthesizeStream(ctx context.Context, req *SynthRequest, w io.Writer) error
+	// SynthesizeBatch 批量合成，结果与请求一一对应
+	SynthesizeBatch(ctx context.Context, reqs []*SynthRequest) ([]*SynthResult, error)
+}
+
+// SynthConfig 创建合成器的配置
+type SynthConfig struct {
+	AppID   string
+	Token   string
+	Cluster string
+
+	// DryRun 为true时不访问openspeech，生成确定性的占位音频，便于离线开发
+	DryRun bool
+	// DryRunTone 为true时占位音频使用正弦音而不是静音(mp3编码始终为静音)
+	DryRunTone bool
+}
+
+// NewSynthesizer 按配置创建合成器
+func NewSynthesizer(cfg SynthConfig) Synthesizer {
+	if cfg.DryRun {
+		return &DryRunSynthesizer{Tone: cfg.DryRunTone}
+	}
+	return NewTTSWsClient(cfg.AppID, cfg.Token, cfg.Cluster)
+}
+
+// withRequest 返回应用了请求级参数的客户端副本
+func (t *TTSWsClient) withRequest(req *SynthRequest) *TTSWsClient {
+	c := *t
+	if req.Encoding != "" {
+		c.encoding = req.Encoding
+	}
+	if req.SampleRate > 0 {
+		c.rate = req.SampleRate
+	}
+	if req.SpeedRatio > 0 {
+		c.speed_ratio = req.SpeedRatio
+	}
+	return &c
+}
+
+// validate 在发送前检查请求，失败时返回400的*StatusError
+func (t *TTSWsClient) validate(req *SynthRequest) error {
+	if strings.TrimSpace(req.Text) == "" {
+		return invalidRequest(errors.New("empty text"))
+	}
+	switch t.encoding {
+	case "mp3", "pcm", "wav", "ogg_opus":
+	default:
+		return invalidRequest(fmt.Errorf("unsupported encoding: %s", t.encoding))
+	}
+	return nil
+}
+
+// ttsErrorStatus 将TTS服务端错误码归入HTTP状态码，未知错误码视为服务端错误
+func ttsErrorStatus(code int32) int {
+	switch code {
+	case 3001, 3010, 3011: // 无效请求、文本超长、无效文本
+		return http.StatusBadRequest
+	case 3003: // 并发超限
+		return http.StatusTooManyRequests
+	case 3050: // 音色不存在
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func (t *TTSWsClient) sampleRate() int {
+	if t.rate > 0 {
+		return t.rate
+	}
+	return defaultTTSSampleRate
+}
+
+// ttsFrontend 前端消息中的时间戳信息，时间单位为秒
+type ttsFrontend struct {
+	Words []struct {
+		Word      string  `json:"word"`
+		StartTime float64 `json:"start_time"`
+		EndTime   float64 `json:"end_time"`
+	} `json:"words"`
+}
+
+// parseFrontendWords 解析前端消息中的字级别时间戳
+func parseFrontendWords(msg []byte) ([]WordTiming, error) {
+	var fe ttsFrontend
+	if err := json.Unmarshal(msg, &fe); err != nil {
+		return nil, fmt.Errorf("decode frontend message failed: %v", err)
+	}
+	words := make([]WordTiming, 0, len(fe.Words))
+	for _, w := range fe.Words {
+		words = append(words, WordTiming{
+			Word:  w.Word,
+			Start: time.Duration(w.StartTime * float64(time.Second)),
+			End:   time.Duration(w.EndTime * float64(time.Second)),
+		})
+	}
+	return words, nil
+}
+
+// audioDuration 根据编码计算音频时长
+func audioDuration(audio []byte, encoding string, sampleRate int) (time.Duration, error) {
+	switch encoding {
+	case "mp3":
+		return mp3Duration(audio)
+	case "wav":
+		a, err := ReadWAV(audio)
+		if err != nil {
+			return 0, err
+		}
+		return a.Duration(), nil
+	case "pcm":
+		return DecodePCM16(audio, sampleRate, 1).Duration(), nil
+	}
+	return 0, fmt.Errorf("unsupported encoding: %s", encoding)
+}
+
+// Synthesize 实现Synthesizer接口，使用query模式合成并请求字级别时间戳
+func (t *TTSWsClient) Synthesize(ctx context.Context, req *SynthRequest) (*SynthResult, error) {
+	c := t.withRequest(req)
+	c.withFrontend = true
+	if err := c.validate(req); err != nil {
+		return nil, err
+	}
+	input, err := c.SetupInput(req.Text, req.VoiceType, optQuery)
+	if err != nil {
+		return nil, fmt.Errorf("request setup failed: %v", err)
+	}
+
+	var audio bytes.Buffer
+	var words []WordTiming
+	err = c.roundTrip(ctx, input, &audio, func(msg []byte) {
+		parsed, err := parseFrontendWords(msg)
+		if err != nil {
+			log.Printf("ignore frontend message: %v", err)
+			return
+		}
+		words = append(words, parsed...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("synthesis failed: %w", err)
+	}
+	if audio.Len() == 0 {
+		return nil, errors.New("synthesis failed: no audio received")
+	}
+
+	result := &SynthResult{
+		Audio:      audio.Bytes(),
+		Encoding:   c.encoding,
+		SampleRate: c.sampleRate(),
+		Words:      words,
+	}
+	if d, err := audioDuration(result.Audio, c.encoding, result.SampleRate); err == nil {
+		result.Duration = d
+	}
+	return result, nil
+}
+
+// Fingerprint 实现SynthesizerFingerprint接口，包含请求未指定时使用的默认配置，不含鉴权信息
+func (t *TTSWsClient) Fingerprint() string {
+	data, _ := json.Marshal(struct {
+		AppID, Cluster, VoiceType, Encoding string
+		Rate                                int
+		Speed, Volume                       float32
+		Pitch                               string
+		Frontend                            bool
+	}{t.appid, t.clusterid, t.voiceType, t.encoding, t.rate, t.speed_ratio, t.volume_ratio, t.pitch_ratio, t.withFrontend})
+	return string(data)
+}
+
+// SynthesizeStream 实现Synthesizer接口，使用submit模式流式合成
+func (t *TTSWsClient) SynthesizeStream(ctx context.Context, req *SynthRequest, w io.Writer) error {
+	c := t.withRequest(req)
+	if err := c.validate(req); err != nil {
+		return err
+	}
+	input, err := c.SetupInput(req.Text, req.VoiceType, optSubmit)
+	if err != nil {
+		return fmt.Errorf("request setup failed: %v", err)
+	}
+	if err := c.roundTrip(ctx, input, w, nil); err != nil {
+		return fmt.Errorf("stream synthesis completed with error: %w", err)
+	}
+	return nil
+}
+
+// SynthesizeBatch 实现Synthesizer接口
+func (t *TTSWsClient) SynthesizeBatch(ctx context.Context, reqs []*SynthRequest) ([]*SynthResult, error) {
+	return synthesizeBatch(ctx, t, reqs)
+}
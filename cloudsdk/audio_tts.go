@@ -3,6 +3,7 @@ package cloudsdk
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -49,15 +50,24 @@ type TTSWsClient struct {
 	appid        string
 	apptoken     string
 	clusterid    string
+	voiceType    string  // 调用时未指定音色时使用，默认值""
 	encoding     string  // 默认值"mp3"
+	rate         int     // 默认值0，即使用服务端默认采样率(24000)
 	speed_ratio  float32 // 默认值1.0
 	volume_ratio float32 // 默认值1.0
 	pitch_ratio  string  // 默认值""
+	withFrontend bool    // 是否请求返回字级别时间戳，默认值false
 }
 
 type synResp struct {
-	Audio  []byte
-	IsLast bool
+	Audio    []byte
+	Frontend []byte
+	IsLast   bool
+}
+
+// messageReader 抽象了websocket连接的读取能力，便于在测试中替换
+type messageReader interface {
+	ReadMessage() (messageType int, p []byte, err error)
 }
 
 // version: b0001 (4 bits)
@@ -82,8 +92,29 @@ func NewTTSWsClient(appid, apptoken, clusterid string) *TTSWsClient {
 	}
 }
 
+// WithVoiceType 设置默认音色，合成时voiceType参数为空则使用该音色
+func (t *TTSWsClient) WithVoiceType(voiceType string) *TTSWsClient {
+	t.voiceType = voiceType
+	return t
+}
+
+// WithEncoding 设置输出音频编码，如 "mp3"、"pcm"、"wav"
+func (t *TTSWsClient) WithEncoding(encoding string) *TTSWsClient {
+	t.encoding = encoding
+	return t
+}
+
+// WithSampleRate 设置输出音频采样率，可选 8000/16000/24000
+func (t *TTSWsClient) WithSampleRate(rate int) *TTSWsClient {
+	t.rate = rate
+	return t
+}
+
 func (t *TTSWsClient) SetupInput(text, voiceType, opt string) (jsonParams []byte, err error) {
 	reqID := uuid.Must(uuid.NewV4(), err).String()
+	if voiceType == "" {
+		voiceType = t.voiceType
+	}
 	params := map[string]map[string]interface{}{
 		"app": {
 			"appid":   t.appid,
@@ -108,6 +139,14 @@ func (t *TTSWsClient) SetupInput(text, voiceType, opt string) (jsonParams []byte
 		},
 	}
 
+	if t.rate > 0 {
+		params["audio"]["rate"] = t.rate
+	}
+	if t.withFrontend {
+		params["request"]["with_frontend"] = 1
+		params["request"]["frontend_type"] = "unitTson"
+	}
+
 	return json.Marshal(params)
 }
 
@@ -197,12 +236,16 @@ func (t *TTSWsClient) parseResponse(res []byte) (synResp, error) {
 			sequenceNumber := int32(binary.BigEndian.Uint32(payload[0:4]))
 			payloadSize := int32(binary.BigEndian.Uint32(payload[4:8]))
 			payload = payload[8:]
+			if int(payloadSize) != len(payload) {
+				return resp, fmt.Errorf("audio payload size mismatch, declared %d bytes but got %d",
+					payloadSize, len(payload))
+			}
 
 			resp.Audio = append(resp.Audio, payload...)
 			fmt.Printf("             Sequence number: %d\n", sequenceNumber)
 			fmt.Printf("                Payload size: %d\n", payloadSize)
 
-			if sequenceNumber < 0 {
+			if sequenceNumber < 0 || messageTypeSpecificFlags&0x02 != 0 {
 				resp.IsLast = true
 			}
 		}
@@ -213,7 +256,12 @@ func (t *TTSWsClient) parseResponse(res []byte) (synResp, error) {
 				len(payload))
 		}
 		code := int32(binary.BigEndian.Uint32(payload[0:4]))
+		msgSize := int32(binary.BigEndian.Uint32(payload[4:8]))
 		errMsg := payload[8:]
+		if int(msgSize) != len(errMsg) {
+			return resp, fmt.Errorf("error message size mismatch, declared %d bytes but got %d",
+				msgSize, len(errMsg))
+		}
 
 		if messageCompression == 1 {
 			decompressed, err := gzipDecompress(errMsg)
@@ -225,7 +273,7 @@ func (t *TTSWsClient) parseResponse(res []byte) (synResp, error) {
 
 		fmt.Printf("                  Error code: %d\n", code)
 		fmt.Printf("                   Error msg: %q\n", string(errMsg))
-		return resp, fmt.Errorf("server error %d: %s", code, string(errMsg))
+		return resp, &StatusError{Code: ttsErrorStatus(code), Err: fmt.Errorf("server error %d: %s", code, string(errMsg))}
 
 	case 0x0c: // frontend server response
 		if len(payload) < 4 {
@@ -234,6 +282,10 @@ func (t *TTSWsClient) parseResponse(res []byte) (synResp, error) {
 		}
 		msgSize := int32(binary.BigEndian.Uint32(payload[0:4]))
 		payload = payload[4:]
+		if int(msgSize) != len(payload) {
+			return resp, fmt.Errorf("frontend message size mismatch, declared %d bytes but got %d",
+				msgSize, len(payload))
+		}
 
 		if messageCompression == 1 {
 			decompressed, err := gzipDecompress(payload)
@@ -243,6 +295,7 @@ func (t *TTSWsClient) parseResponse(res []byte) (synResp, error) {
 			payload = decompressed
 		}
 
+		resp.Frontend = payload
 		fmt.Printf("            Frontend message: %q\n", string(payload))
 		fmt.Printf("                 Message size: %d\n", msgSize)
 
@@ -256,7 +309,7 @@ func (t *TTSWsClient) parseResponse(res []byte) (synResp, error) {
 	return resp, nil
 }
 
-func (t *TTSWsClient) connect() (*websocket.Conn, error) {
+func (t *TTSWsClient) connect(ctx context.Context) (*websocket.Conn, error) {
 	u := url.URL{
 		Scheme: "wss",
 		Host:   "openspeech.bytedance.com",
@@ -264,9 +317,14 @@ func (t *TTSWsClient) connect() (*websocket.Conn, error) {
 	}
 	header := http.Header{"Authorization": []string{fmt.Sprintf("Bearer;%s", t.apptoken)}}
 
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), header)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
 	if err != nil {
-		return nil, fmt.Errorf("websocket connection failed: %v", err)
+		err = fmt.Errorf("websocket connection failed: %v", err)
+		if resp != nil {
+			// 握手被拒绝，如鉴权失败
+			return nil, &StatusError{Code: resp.StatusCode, Err: err}
+		}
+		return nil, err
 	}
 	return conn, nil
 }
@@ -289,40 +347,86 @@ func (t *TTSWsClient) buildRequest(input []byte) ([]byte, error) {
 	return request, nil
 }
 
-// NonStreamSynth 执行一次性语音合成
-func (t *TTSWsClient) NonStreamSynth(text, voiceType, outFile string) error {
-	input, err := t.SetupInput(text, voiceType, optQuery)
-	if err != nil {
-		return fmt.Errorf("request setup failed: %v", err)
+// receiveAudio 持续读取服务端消息直到收到最后一帧音频
+// 前端消息(0x0c)会被跳过，错误消息(0x0f)会中止读取；出错时返回已收到的音频
+func (t *TTSWsClient) receiveAudio(conn messageReader) ([]byte, error) {
+	var audio bytes.Buffer
+	err := t.receiveAudioTo(conn, &audio, nil)
+	return audio.Bytes(), err
+}
+
+// receiveAudioTo 与receiveAudio相同，但每收到一帧音频就写入w
+// onFrontend不为空时，前端消息的内容会交给它处理
+func (t *TTSWsClient) receiveAudioTo(conn messageReader, w io.Writer, onFrontend func([]byte)) error {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("read message failed: %v", err)
+		}
+
+		resp, err := t.parseResponse(message)
+		if err != nil {
+			return fmt.Errorf("parse response failed: %w", err)
+		}
+
+		if len(resp.Frontend) > 0 && onFrontend != nil {
+			onFrontend(resp.Frontend)
+		}
+		if len(resp.Audio) > 0 {
+			if _, err := w.Write(resp.Audio); err != nil {
+				return fmt.Errorf("write audio failed: %v", err)
+			}
+		}
+		if resp.IsLast {
+			return nil
+		}
 	}
-	fmt.Printf("Request payload: %s\n", string(input))
+}
 
+// roundTrip 发送一次合成请求，将返回的音频写入w，ctx结束时连接会被关闭
+func (t *TTSWsClient) roundTrip(ctx context.Context, input []byte, w io.Writer, onFrontend func([]byte)) error {
 	request, err := t.buildRequest(input)
 	if err != nil {
 		return err
 	}
 
-	conn, err := t.connect()
+	conn, err := t.connect(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
 
 	if err := conn.WriteMessage(websocket.BinaryMessage, request); err != nil {
 		return fmt.Errorf("write request failed: %v", err)
 	}
 
-	_, message, err := conn.ReadMessage()
-	if err != nil {
-		return fmt.Errorf("read response failed: %v", err)
+	if err := t.receiveAudioTo(conn, w, onFrontend); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
 	}
+	return nil
+}
 
-	resp, err := t.parseResponse(message)
+// NonStreamSynth 执行一次性语音合成
+func (t *TTSWsClient) NonStreamSynth(text, voiceType, outFile string) error {
+	input, err := t.SetupInput(text, voiceType, optQuery)
 	if err != nil {
-		return fmt.Errorf("parse response failed: %v", err)
+		return fmt.Errorf("request setup failed: %v", err)
+	}
+
+	var audio bytes.Buffer
+	if err := t.roundTrip(context.Background(), input, &audio, nil); err != nil {
+		return fmt.Errorf("synthesis failed: %v", err)
+	}
+	if audio.Len() == 0 {
+		return errors.New("synthesis failed: no audio received")
 	}
 
-	if err := os.WriteFile(outFile, resp.Audio, 0644); err != nil {
+	if err := os.WriteFile(outFile, audio.Bytes(), 0644); err != nil {
 		return fmt.Errorf("write output file failed: %v", err)
 	}
 
@@ -331,58 +435,43 @@ func (t *TTSWsClient) NonStreamSynth(text, voiceType, outFile string) error {
 
 // StreamSynth 执行流式语音合成
 func (t *TTSWsClient) StreamSynth(text, voiceType, outFile string) error {
-	input, err := t.SetupInput(text, voiceType, optSubmit)
-	if err != nil {
-		return fmt.Errorf("request setup failed: %v", err)
-	}
-	fmt.Printf("Request payload: %s\n", string(input))
+	var audio bytes.Buffer
+	synthErr := t.StreamSynthTo(text, voiceType, &audio)
 
-	request, err := t.buildRequest(input)
-	if err != nil {
-		return err
+	if audio.Len() > 0 {
+		if err := os.WriteFile(outFile, audio.Bytes(), 0644); err != nil {
+			return fmt.Errorf("write output file failed: %v", err)
+		}
 	}
 
-	conn, err := t.connect()
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
+	return synthErr
+}
 
-	if err := conn.WriteMessage(websocket.BinaryMessage, request); err != nil {
-		return fmt.Errorf("write request failed: %v", err)
+// StreamSynthTo 执行流式语音合成，每收到一段音频就写入w
+func (t *TTSWsClient) StreamSynthTo(text, voiceType string, w io.Writer) error {
+	input, err := t.SetupInput(text, voiceType, optSubmit)
+	if err != nil {
+		return fmt.Errorf("request setup failed: %v", err)
 	}
 
-	var audio []byte
-	var lastErr error
-
-	for {
-		_, message, err := conn.ReadMessage()
-		if err != nil {
-			lastErr = fmt.Errorf("read message failed: %v", err)
-			break
-		}
-
-		resp, err := t.parseResponse(message)
-		if err != nil {
-			lastErr = fmt.Errorf("parse response failed: %v", err)
-			break
-		}
-
-		audio = append(audio, resp.Audio...)
-		if resp.IsLast {
-			break
-		}
+	if err := t.roundTrip(context.Background(), input, w, nil); err != nil {
+		return fmt.Errorf("stream synthesis completed with error: %v", err)
 	}
+	return nil
+}
 
-	if len(audio) > 0 {
-		if err := os.WriteFile(outFile, audio, 0644); err != nil {
-			return fmt.Errorf("write output file failed: %v", err)
-		}
+// StreamSynthG711 以pcm编码流式合成，并实时转换为8kHz单声道G.711数据写入w
+func (t *TTSWsClient) StreamSynthG711(text, voiceType string, law G711Law, w io.Writer) error {
+	pcmClient := *t
+	pcmClient.encoding = "pcm"
+	srcRate := t.rate
+	if srcRate == 0 {
+		srcRate = 24000
 	}
 
-	if lastErr != nil {
-		return fmt.Errorf("stream synthesis completed with error: %v", lastErr)
+	enc := NewG711Encoder(w, law, srcRate, 1)
+	if err := pcmClient.StreamSynthTo(text, voiceType, enc); err != nil {
+		return err
 	}
-
-	return nil
+	return enc.Close()
 }
@@ -1,10 +1,14 @@
 package cloudsdk
 
 import (
+	"encoding/binary"
+	"io"
 	"os"
 	"testing"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestNonStreamSynth_Success 测试成功的语音合成场景
@@ -31,3 +35,77 @@ func TestNonStreamSynth_Success(t *testing.T) {
 	assert.NoError(t, err)
 
 }
+
+// fakeMessageReader 按顺序返回预置的服务端消息
+type fakeMessageReader struct {
+	messages [][]byte
+}
+
+func (f *fakeMessageReader) ReadMessage() (int, []byte, error) {
+	if len(f.messages) == 0 {
+		return 0, nil, io.EOF
+	}
+	msg := f.messages[0]
+	f.messages = f.messages[1:]
+	return websocket.BinaryMessage, msg, nil
+}
+
+func ttsAudioFrame(seq int32, audio []byte) []byte {
+	frame := []byte{0x11, 0xb1, 0x00, 0x00}
+	if seq < 0 {
+		frame[1] = 0xb3
+	}
+	frame = binary.BigEndian.AppendUint32(frame, uint32(seq))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(audio)))
+	return append(frame, audio...)
+}
+
+func ttsFrontendFrame(msg string) []byte {
+	frame := []byte{0x11, 0xc0, 0x10, 0x00}
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+func ttsErrorFrame(code int32, msg string) []byte {
+	frame := []byte{0x11, 0xf0, 0x10, 0x00}
+	frame = binary.BigEndian.AppendUint32(frame, uint32(code))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+func TestReceiveAudio_MultiFrame(t *testing.T) {
+	client := NewTTSWsClient("appid", "token", "cluster")
+	reader := &fakeMessageReader{messages: [][]byte{
+		ttsFrontendFrame(`{"words":[]}`),
+		ttsAudioFrame(1, []byte("abc")),
+		ttsFrontendFrame(`{"phonemes":[]}`),
+		ttsAudioFrame(2, []byte("def")),
+		ttsAudioFrame(-3, []byte("gh")),
+	}}
+
+	audio, err := client.receiveAudio(reader)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("abcdefgh"), audio)
+	assert.Empty(t, reader.messages)
+}
+
+func TestReceiveAudio_ErrorFrame(t *testing.T) {
+	client := NewTTSWsClient("appid", "token", "cluster")
+	reader := &fakeMessageReader{messages: [][]byte{
+		ttsAudioFrame(1, []byte("abc")),
+		ttsErrorFrame(3001, "invalid voice_type"),
+	}}
+
+	audio, err := client.receiveAudio(reader)
+	assert.ErrorContains(t, err, "invalid voice_type")
+	assert.Equal(t, []byte("abc"), audio)
+}
+
+func TestReceiveAudio_SizeMismatch(t *testing.T) {
+	client := NewTTSWsClient("appid", "token", "cluster")
+	frame := ttsAudioFrame(-1, []byte("abcd"))
+	reader := &fakeMessageReader{messages: [][]byte{frame[:len(frame)-1]}}
+
+	_, err := client.receiveAudio(reader)
+	assert.ErrorContains(t, err, "size mismatch")
+}
@@ -0,0 +1,317 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VADOptions 基于帧能量的语音活动检测参数
+type VADOptions struct {
+	FrameMs      int      // 能量检测的帧长，默认 20ms
+	ThresholdDB  *float64 // 语音帧能量的最低阈值(dBFS)，默认 -45dB
+	MarginDB     *float64 // 语音帧需高出底噪的分贝数，默认 10dB，DB(0)表示不要求高出底噪，底噪取帧能量的10%分位
+	MinSpeechMs  int      // 短于该时长的语音段视为噪声丢弃，默认 200ms
+	MinSilenceMs int      // 短于该时长的静音不切分语音段，默认 300ms
+	PaddingMs    int      // 语音段前后保留的余量，默认 100ms
+}
+
+func (o *VADOptions) withDefaults() VADOptions {
+	opts := *o
+	if opts.FrameMs <= 0 {
+		opts.FrameMs = 20
+	}
+	if opts.ThresholdDB == nil {
+		opts.ThresholdDB = DB(-45)
+	}
+	if opts.MarginDB == nil {
+		opts.MarginDB = DB(10)
+	}
+	if opts.MinSpeechMs <= 0 {
+		opts.MinSpeechMs = 200
+	}
+	if opts.MinSilenceMs <= 0 {
+		opts.MinSilenceMs = 300
+	}
+	if opts.PaddingMs <= 0 {
+		opts.PaddingMs = 100
+	}
+	return opts
+}
+
+// SpeechRegion 音频中的一段语音
+type SpeechRegion struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// DetectSpeech 检测音频中的语音段，结果按时间排序且互不重叠
+func DetectSpeech(a *PCMAudio, opts VADOptions) []SpeechRegion {
+	o := opts.withDefaults()
+	frameLen := a.SampleRate * o.FrameMs / 1000 * a.Channels
+	if frameLen <= 0 || len(a.Samples) == 0 {
+		return nil
+	}
+
+	energies := make([]float64, 0, len(a.Samples)/frameLen+1)
+	for i := 0; i < len(a.Samples); i += frameLen {
+		energies = append(energies, frameEnergyDB(a.Samples[i:min(i+frameLen, len(a.Samples))]))
+	}
+
+	// 自适应阈值：底噪较高的录音需要更高的阈值，但不超过较响帧以下20dB，避免几乎没有停顿的录音被整体判为静音
+	sorted := append([]float64(nil), energies...)
+	sort.Float64s(sorted)
+	floor, loud := sorted[len(sorted)/10], sorted[len(sorted)*95/100]
+	threshold := math.Max(*o.ThresholdDB, math.Min(floor+*o.MarginDB, loud-20))
+
+	frame := time.Duration(o.FrameMs) * time.Millisecond
+	total := a.Duration()
+	// 向上取整，帧长大于MinSilenceMs时至少需要一个静音帧
+	minSilence := max(1, (o.MinSilenceMs+o.FrameMs-1)/o.FrameMs)
+	var regions []SpeechRegion
+	start, silent := -1, 0
+	closeRegion := func(end int) {
+		if time.Duration(end-start)*frame >= time.Duration(o.MinSpeechMs)*time.Millisecond {
+			regions = append(regions, SpeechRegion{Start: time.Duration(start) * frame, End: min(time.Duration(end)*frame, total)})
+		}
+		start = -1
+	}
+	for i, e := range energies {
+		switch {
+		case e >= threshold:
+			if start < 0 {
+				start = i
+			}
+			silent = 0
+		case start >= 0:
+			silent++
+			if silent >= minSilence {
+				closeRegion(i - silent + 1)
+			}
+		}
+	}
+	if start >= 0 {
+		closeRegion(len(energies) - silent)
+	}
+
+	// 加上前后余量，余量重叠的相邻语音段合并
+	padding := time.Duration(o.PaddingMs) * time.Millisecond
+	var padded []SpeechRegion
+	for _, r := range regions {
+		r.Start = max(r.Start-padding, 0)
+		r.End = min(r.End+padding, total)
+		if n := len(padded); n > 0 && r.Start <= padded[n-1].End {
+			padded[n-1].End = r.End
+			continue
+		}
+		padded = append(padded, r)
+	}
+	return padded
+}
+
+// SpeechSegment 切分后的一段音频，Start/End为在原音频中的位置
+type SpeechSegment struct {
+	Start time.Duration
+	End   time.Duration
+	Audio *PCMAudio
+}
+
+// SegmentOptions 长音频切分参数
+type SegmentOptions struct {
+	VAD        VADOptions
+	MaxSegment time.Duration // 每段的最大时长，默认 50s；单个语音段超出时强制切分
+}
+
+// slice 截取[start, end)范围内的音频
+func (a *PCMAudio) slice(start, end time.Duration) *PCMAudio {
+	from := int(int64(start) * int64(a.SampleRate) / int64(time.Second))
+	to := min(int(int64(end)*int64(a.SampleRate)/int64(time.Second)), a.Frames())
+	return &PCMAudio{SampleRate: a.SampleRate, Channels: a.Channels, Samples: a.Samples[from*a.Channels : to*a.Channels]}
+}
+
+// SplitAtSilence 将长音频在静音处切分为不超过MaxSegment的片段，只保留包含语音的部分
+func SplitAtSilence(a *PCMAudio, opts SegmentOptions) []SpeechSegment {
+	maxSegment := opts.MaxSegment
+	if maxSegment <= 0 {
+		maxSegment = 50 * time.Second
+	}
+
+	// 超长的语音段先按最大时长强制切开
+	var regions []SpeechRegion
+	for _, r := range DetectSpeech(a, opts.VAD) {
+		for r.End-r.Start > maxSegment {
+			regions = append(regions, SpeechRegion{Start: r.Start, End: r.Start + maxSegment})
+			r.Start += maxSegment
+		}
+		regions = append(regions, r)
+	}
+
+	// 贪心合并相邻语音段，直到再加一段会超过最大时长
+	var segments []SpeechSegment
+	for _, r := range regions {
+		if n := len(segments); n > 0 && r.End-segments[n-1].Start <= maxSegment {
+			segments[n-1].End = r.End
+			continue
+		}
+		segments = append(segments, SpeechSegment{Start: r.Start, End: r.End})
+	}
+	for i := range segments {
+		segments[i].Audio = a.slice(segments[i].Start, segments[i].End)
+	}
+	return segments
+}
+
+// LongAudioOptions 长音频识别参数
+type LongAudioOptions struct {
+	SegmentOptions
+	Concurrency  int           // 并行识别的片段数，默认 1
+	MaxAttempts  int           // 每段最多尝试的次数，默认 3
+	RetryBackoff time.Duration // 首次重试前的等待时间，之后逐次翻倍，默认 1s
+}
+
+// maxRetryBackoff 片段重试间隔的上限
+const maxRetryBackoff = 30 * time.Second
+
+// SegmentError 重试后仍识别失败的片段，Start/End为在原音频中的位置
+type SegmentError struct {
+	Start time.Duration
+	End   time.Duration
+	Err   error
+}
+
+func (e SegmentError) Error() string {
+	return fmt.Sprintf("segment %s-%s: %v", e.Start, e.End, e.Err)
+}
+
+// PartialResultError 部分片段识别失败，RecognizeLong会同时返回其余片段合并的结果
+type PartialResultError struct {
+	Failed []SegmentError
+}
+
+func (e *PartialResultError) Error() string {
+	return fmt.Sprintf("%d segments failed, first: %v", len(e.Failed), e.Failed[0])
+}
+
+// RecognizeLong 将长音频在静音处切分后逐段识别，并把分句和字的时间戳还原到原音频的时间轴
+// 每段使用独立的连接，避免数小时的音频依赖单个会话；失败的片段按退避间隔重试，
+// 重试后仍失败时返回其余片段合并的结果和*PartialResultError
+func (c *AsrWsClient) RecognizeLong(ctx context.Context, a *PCMAudio, opts LongAudioOptions) (*AsrResult, error) {
+	if a == nil || a.SampleRate <= 0 || a.Channels <= 0 {
+		return nil, errors.New("invalid audio")
+	}
+	segments := SplitAtSilence(a, opts.SegmentOptions)
+
+	config := *c.config
+	config.Format = "pcm"
+	config.Rate = a.SampleRate
+	config.Channel = a.Channels
+	config.Bits = 16
+	config.Float = false
+	// 需要分句的时间戳才能还原到原音频的时间轴
+	config.Options.ShowUtterances = true
+	client := *c
+	client.config = &config
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	results := make([]*AsrResult, len(segments))
+	errs := make([]error, len(segments))
+	err := runBatchN(ctx, len(segments), concurrency, func(ctx context.Context, i int) error {
+		results[i], errs[i] = client.recognizeSegment(ctx, segments[i], &opts)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merged := mergeSegmentResults(segments, results, a.Duration())
+	var failed []SegmentError
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, SegmentError{Start: segments[i].Start, End: segments[i].End, Err: err})
+		}
+	}
+	if len(failed) > 0 {
+		return merged, &PartialResultError{Failed: failed}
+	}
+	return merged, nil
+}
+
+// recognizeSegment 识别一个片段，网络错误和服务端5xx错误时按退避间隔重试
+func (c *AsrWsClient) recognizeSegment(ctx context.Context, seg SpeechSegment, opts *LongAudioOptions) (*AsrResult, error) {
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	var result *AsrResult
+	attempt := 0
+	err := pollWithBackoff(ctx, backoff, max(backoff, maxRetryBackoff), func() (bool, error) {
+		resp, err := c.RecognizeReader(ctx, bytes.NewReader(seg.Audio.Bytes()))
+		if err == nil {
+			result = resp.Result
+			return true, nil
+		}
+		attempt++
+		if attempt >= attempts || ctx.Err() != nil || !retryable(err) {
+			return false, err
+		}
+		log.Printf("segment %s-%s failed (attempt %d/%d), retrying: %v", seg.Start, seg.End, attempt, attempts, err)
+		return false, nil
+	})
+	return result, err
+}
+
+// mergeSegmentResults 合并各片段的识别结果，时间戳加上片段在原音频中的偏移
+func mergeSegmentResults(segments []SpeechSegment, results []*AsrResult, total time.Duration) *AsrResult {
+	merged := &AsrResult{}
+	merged.AudioInfo.Duration = int(total / time.Millisecond)
+	var texts []string
+	for i, r := range results {
+		if r == nil || r.Text() == "" && len(r.Utterances()) == 0 {
+			continue
+		}
+		offset := int(segments[i].Start / time.Millisecond)
+		texts = append(texts, r.Text())
+
+		utterances := r.Utterances()
+		if len(utterances) == 0 {
+			// 服务端未返回分句时整段作为一个分句
+			utterances = []AsrUtterance{{Text: r.Text(), EndTime: int((segments[i].End - segments[i].Start) / time.Millisecond), Definite: true}}
+		}
+		for _, u := range utterances {
+			u.StartTime += offset
+			u.EndTime += offset
+			words := make([]AsrWord, len(u.Words))
+			for j, w := range u.Words {
+				w.StartTime += offset
+				w.EndTime += offset
+				words[j] = w
+			}
+			u.Words = words
+			merged.Result.Utterances = append(merged.Result.Utterances, u)
+		}
+	}
+	// 拉丁文字的片段之间用空格分隔，中文直接拼接，与FormatTranscript一致
+	var b strings.Builder
+	for i, text := range texts {
+		if i > 0 && needsSpace(texts[i-1], text) {
+			b.WriteString(" ")
+		}
+		b.WriteString(text)
+	}
+	merged.Result.Text = b.String()
+	return merged
+}
@@ -0,0 +1,175 @@
+package cloudsdk
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// speechBursts 在低底噪上按给定区间(毫秒)放置正弦音，模拟有停顿的讲话
+func speechBursts(rate int, total int, bursts ...[2]int) *PCMAudio {
+	rng := rand.New(rand.NewSource(1))
+	a := &PCMAudio{SampleRate: rate, Channels: 1, Samples: make([]int16, rate*total/1000)}
+	for i := range a.Samples {
+		a.Samples[i] = int16(rng.Intn(40) - 20)
+	}
+	for _, b := range bursts {
+		tone := sineTone(rate, rate*(b[1]-b[0])/1000, 300, 6000)
+		copy(a.Samples[rate*b[0]/1000:], tone.Samples)
+	}
+	return a
+}
+
+func TestDetectSpeech(t *testing.T) {
+	a := speechBursts(16000, 5000, [2]int{500, 1500}, [2]int{1600, 2000}, [2]int{3000, 4000}, [2]int{4500, 4560})
+	regions := DetectSpeech(a, VADOptions{})
+
+	// 100ms的停顿不切分，60ms的语音视为噪声，前后各保留100ms余量
+	require.Len(t, regions, 2)
+	assert.InDelta(t, 400*time.Millisecond, regions[0].Start, float64(20*time.Millisecond))
+	assert.InDelta(t, 2100*time.Millisecond, regions[0].End, float64(20*time.Millisecond))
+	assert.InDelta(t, 2900*time.Millisecond, regions[1].Start, float64(20*time.Millisecond))
+	assert.InDelta(t, 4100*time.Millisecond, regions[1].End, float64(20*time.Millisecond))
+
+	assert.Empty(t, DetectSpeech(speechBursts(16000, 1000), VADOptions{}))
+	assert.Len(t, DetectSpeech(sineTone(16000, 16000, 300, 6000), VADOptions{}), 1)
+}
+
+func TestDetectSpeech_Options(t *testing.T) {
+	// 200ms的帧不足以构成300ms的静音，中间一帧的停顿不切分
+	a := speechBursts(16000, 3000, [2]int{0, 1000}, [2]int{1200, 2000})
+	regions := DetectSpeech(a, VADOptions{FrameMs: 200, MinSilenceMs: 300, PaddingMs: 1})
+	require.Len(t, regions, 1)
+	assert.InDelta(t, 2000*time.Millisecond, regions[0].End, float64(time.Millisecond))
+
+	// 显式的0dB余量和极低阈值使略高于底噪的帧也算作语音，停顿不再切分
+	a = speechBursts(16000, 3000, [2]int{0, 1000}, [2]int{2000, 3000})
+	assert.Len(t, DetectSpeech(a, VADOptions{PaddingMs: 1}), 2)
+	regions = DetectSpeech(a, VADOptions{ThresholdDB: DB(-120), MarginDB: DB(0), PaddingMs: 1})
+	require.Len(t, regions, 1)
+}
+
+func TestSplitAtSilence(t *testing.T) {
+	a := speechBursts(16000, 10000, [2]int{0, 2000}, [2]int{2500, 4000}, [2]int{5000, 9500})
+	segments := SplitAtSilence(a, SegmentOptions{MaxSegment: 4500 * time.Millisecond})
+
+	// 前两段合并后不超过4.5s；加上余量后4.7s的语音段被强制切开
+	require.Len(t, segments, 3)
+	for _, s := range segments {
+		assert.LessOrEqual(t, s.End-s.Start, 4500*time.Millisecond)
+		assert.Equal(t, int((s.End-s.Start)*16000/time.Second), s.Audio.Frames())
+	}
+	assert.Equal(t, time.Duration(0), segments[0].Start)
+	assert.InDelta(t, 4100*time.Millisecond, segments[0].End, float64(20*time.Millisecond))
+	assert.Equal(t, segments[1].End, segments[2].Start)
+}
+
+func TestAsrWsClient_RecognizeLong(t *testing.T) {
+	server := newFakeAsrServer(t)
+	// 每段返回一个覆盖整段音频的分句，文本为音频时长
+	server.result = func(audio []byte, last bool) interface{} {
+		ms := len(audio) / 32
+		return utteranceResult("seg", map[string]interface{}{
+			"text": "seg", "start_time": 0, "end_time": ms, "definite": true,
+			"words": []interface{}{map[string]interface{}{"text": "seg", "start_time": 10, "end_time": ms}},
+		})
+	}
+	client := newTestAsrClient(server, "pcm").WithOptions(AsrRequestOptions{ShowWords: true})
+
+	a := speechBursts(16000, 8000, [2]int{1000, 2000}, [2]int{5000, 6000})
+	result, err := client.RecognizeLong(context.Background(), a, LongAudioOptions{
+		SegmentOptions: SegmentOptions{MaxSegment: 2 * time.Second},
+		Concurrency:    2,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "seg seg", result.Text())
+	assert.Equal(t, 8000, result.AudioInfo.Duration)
+	utterances := result.Utterances()
+	require.Len(t, utterances, 2)
+	assert.InDelta(t, 900, utterances[0].StartTime, 20)
+	assert.InDelta(t, 2100, utterances[0].EndTime, 20)
+	assert.InDelta(t, 4900, utterances[1].StartTime, 20)
+	assert.Equal(t, utterances[1].StartTime+10, utterances[1].Words[0].StartTime)
+}
+
+func TestMergeSegmentResults_Spacing(t *testing.T) {
+	segments := []SpeechSegment{{End: time.Second}, {Start: time.Second, End: 2 * time.Second}, {Start: 2 * time.Second, End: 3 * time.Second}}
+	results := make([]*AsrResult, 3)
+	for i, text := range []string{"hello", "world", "你好"} {
+		results[i] = &AsrResult{}
+		results[i].Result.Text = text
+	}
+	assert.Equal(t, "hello world你好", mergeSegmentResults(segments, results, 3*time.Second).Text())
+}
+
+func TestAsrWsClient_RecognizeLongRetry(t *testing.T) {
+	server := newFakeAsrServer(t)
+	// 第一次会话失败，重试后成功
+	var sessions int
+	server.fail = func(audio []byte) bool {
+		if len(audio) == 3200 {
+			sessions++
+		}
+		return sessions == 1
+	}
+	client := newTestAsrClient(server, "pcm")
+
+	a := speechBursts(16000, 4000, [2]int{1000, 2000})
+	result, err := client.RecognizeLong(context.Background(), a, LongAudioOptions{RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Text())
+	assert.Equal(t, 2, sessions)
+}
+
+func TestAsrWsClient_RecognizeLongPermanent(t *testing.T) {
+	server := newFakeAsrServer(t)
+	// 请求参数错误重试也不会成功，只尝试一次
+	server.errorCode = 45000001
+	var sessions int
+	server.result = func(audio []byte, last bool) interface{} {
+		if len(audio) == 3200 {
+			sessions++
+		}
+		return nil
+	}
+	client := newTestAsrClient(server, "pcm")
+
+	a := speechBursts(16000, 4000, [2]int{1000, 2000})
+	_, err := client.RecognizeLong(context.Background(), a, LongAudioOptions{RetryBackoff: time.Millisecond})
+	var partial *PartialResultError
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, 1, sessions)
+}
+
+func TestAsrWsClient_RecognizeLongPartial(t *testing.T) {
+	server := newFakeAsrServer(t)
+	server.result = func(audio []byte, last bool) interface{} {
+		return utteranceResult("ok", utterance("ok", 0, 100, true))
+	}
+	// 超过1.5s的片段总是失败
+	server.fail = func(audio []byte) bool { return len(audio) > 48000 }
+	client := newTestAsrClient(server, "pcm")
+
+	a := speechBursts(16000, 8000, [2]int{1000, 2000}, [2]int{4000, 7000})
+	result, err := client.RecognizeLong(context.Background(), a, LongAudioOptions{
+		SegmentOptions: SegmentOptions{MaxSegment: 4 * time.Second},
+		MaxAttempts:    2,
+		RetryBackoff:   time.Millisecond,
+	})
+	var partial *PartialResultError
+	require.ErrorAs(t, err, &partial)
+	require.Len(t, partial.Failed, 1)
+	assert.InDelta(t, 3900*time.Millisecond, partial.Failed[0].Start, float64(20*time.Millisecond))
+	assert.InDelta(t, 7100*time.Millisecond, partial.Failed[0].End, float64(20*time.Millisecond))
+
+	// 成功的片段仍然保留
+	require.NotNil(t, result)
+	assert.Equal(t, "ok", result.Text())
+	require.Len(t, result.Utterances(), 1)
+	assert.InDelta(t, 900, result.Utterances()[0].StartTime, 20)
+}
@@ -0,0 +1,261 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// PCMAudio 16位小端PCM音频，多声道时采样点交错存储
+type PCMAudio struct {
+	SampleRate int
+	Channels   int
+	Samples    []int16
+}
+
+// Frames 返回每个声道的采样点数
+func (a *PCMAudio) Frames() int {
+	if a.Channels == 0 {
+		return 0
+	}
+	return len(a.Samples) / a.Channels
+}
+
+// Duration 返回音频时长
+func (a *PCMAudio) Duration() time.Duration {
+	if a.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(a.Frames()) * time.Second / time.Duration(a.SampleRate)
+}
+
+// Bytes 将采样点序列化为16位小端PCM字节流
+func (a *PCMAudio) Bytes() []byte {
+	out := make([]byte, len(a.Samples)*2)
+	for i, s := range a.Samples {
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(s))
+	}
+	return out
+}
+
+// DecodePCM16 将16位小端PCM字节流解析为PCMAudio，末尾不足一个采样点的字节会被忽略
+func DecodePCM16(data []byte, sampleRate, channels int) *PCMAudio {
+	samples := make([]int16, len(data)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[i*2:]))
+	}
+	return &PCMAudio{SampleRate: sampleRate, Channels: channels, Samples: samples}
+}
+
+// WAV fmt块中的编码类型
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatIEEEFloat  = 0x0003
+	wavFormatALaw       = 0x0006
+	wavFormatMuLaw      = 0x0007
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavUnknownSize 流式写入的WAV文件在长度未知时使用的占位值
+const wavUnknownSize = 0xFFFFFFFF
+
+// maxWAVFmtSize fmt块长度上限，WAVE_FORMAT_EXTENSIBLE也只有40字节，更大的值视为文件损坏
+const maxWAVFmtSize = 64
+
+// WAVFormat WAV文件头中的音频格式
+type WAVFormat struct {
+	FormatTag  uint16 // 编码类型，扩展格式已替换为SubFormat中的实际编码
+	Channels   int
+	SampleRate int
+	Bits       int
+	BlockAlign int
+	DataSize   int64 // data块长度，长度未知时为-1
+}
+
+func wavFormatName(tag uint16) string {
+	switch tag {
+	case wavFormatPCM:
+		return "PCM"
+	case wavFormatIEEEFloat:
+		return "IEEE float"
+	case wavFormatALaw:
+		return "A-law"
+	case wavFormatMuLaw:
+		return "mu-law"
+	case 0x0002, 0x0011:
+		return "ADPCM"
+	case 0x0055:
+		return "MP3"
+	}
+	return fmt.Sprintf("0x%04x", tag)
+}
+
+// g711Law 返回G.711编码WAV的压扩律
+func (f *WAVFormat) g711Law() (G711Law, bool) {
+	switch f.FormatTag {
+	case wavFormatMuLaw:
+		return MuLaw, true
+	case wavFormatALaw:
+		return ALaw, true
+	}
+	return 0, false
+}
+
+// CheckPCM16 检查是否为16位PCM编码，否则返回说明实际编码的错误
+func (f *WAVFormat) CheckPCM16() error {
+	if f.FormatTag != wavFormatPCM {
+		return fmt.Errorf("unsupported wav encoding %s, only 16-bit PCM is supported", wavFormatName(f.FormatTag))
+	}
+	if f.Bits != 16 {
+		return fmt.Errorf("unsupported wav encoding %d-bit PCM, only 16-bit PCM is supported", f.Bits)
+	}
+	if f.Channels <= 0 || f.SampleRate <= 0 {
+		return fmt.Errorf("invalid wav format: %d channels, %d Hz", f.Channels, f.SampleRate)
+	}
+	return nil
+}
+
+// parseWAVFmt 解析fmt块，支持WAVE_FORMAT_EXTENSIBLE
+func parseWAVFmt(body []byte) (*WAVFormat, error) {
+	if len(body) < 16 {
+		return nil, fmt.Errorf("fmt chunk too short: %d bytes", len(body))
+	}
+	f := &WAVFormat{
+		FormatTag:  binary.LittleEndian.Uint16(body[0:2]),
+		Channels:   int(binary.LittleEndian.Uint16(body[2:4])),
+		SampleRate: int(binary.LittleEndian.Uint32(body[4:8])),
+		BlockAlign: int(binary.LittleEndian.Uint16(body[12:14])),
+		Bits:       int(binary.LittleEndian.Uint16(body[14:16])),
+	}
+	if f.FormatTag == wavFormatExtensible {
+		// cbSize(2) wValidBitsPerSample(2) dwChannelMask(4) SubFormat(16)，GUID前两字节为实际编码
+		if len(body) < 40 {
+			return nil, fmt.Errorf("extensible fmt chunk too short: %d bytes", len(body))
+		}
+		f.FormatTag = binary.LittleEndian.Uint16(body[24:26])
+	}
+	return f, nil
+}
+
+// ReadWAVHeader 从r中读取WAV头直到data块起始处，返回后r位于音频数据开头
+// 跳过fmt与data之外的块，并处理奇数长度块的填充字节
+func ReadWAVHeader(r io.Reader) (*WAVFormat, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("failed to read wav header: %v", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF/WAVE file")
+	}
+
+	var format *WAVFormat
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF && format == nil {
+				return nil, errors.New("wav fmt chunk not found")
+			}
+			if err == io.EOF {
+				return nil, errors.New("wav data chunk not found")
+			}
+			return nil, fmt.Errorf("failed to read wav chunk: %v", err)
+		}
+		id := string(hdr[0:4])
+		size := int64(binary.LittleEndian.Uint32(hdr[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size > maxWAVFmtSize {
+				return nil, fmt.Errorf("invalid wav fmt chunk size: %d", size)
+			}
+			body := make([]byte, size+size&1)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("failed to read wav fmt chunk: %v", err)
+			}
+			f, err := parseWAVFmt(body[:size])
+			if err != nil {
+				return nil, err
+			}
+			format = f
+		case "data":
+			if format == nil {
+				return nil, errors.New("wav data chunk found before fmt chunk")
+			}
+			format.DataSize = size
+			if size == wavUnknownSize {
+				format.DataSize = -1
+			}
+			return format, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size&1); err != nil {
+				return nil, fmt.Errorf("failed to skip wav %q chunk: %v", id, err)
+			}
+		}
+	}
+}
+
+// wavData 返回只包含data块内容的Reader，长度未知时读到r结束
+func (f *WAVFormat) wavData(r io.Reader) io.Reader {
+	if f.DataSize < 0 {
+		return r
+	}
+	return io.LimitReader(r, f.DataSize)
+}
+
+// ReadWAV 解析16位PCM编码的WAV数据，data块被截断时使用剩余的全部数据
+func ReadWAV(data []byte) (*PCMAudio, error) {
+	r := bytes.NewReader(data)
+	format, err := ReadWAVHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := format.CheckPCM16(); err != nil {
+		return nil, err
+	}
+	pcm, err := io.ReadAll(format.wavData(r))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wav data: %v", err)
+	}
+	return DecodePCM16(pcm, format.SampleRate, format.Channels), nil
+}
+
+// ReadWAVFile 读取并解析WAV文件
+func ReadWAVFile(path string) (*PCMAudio, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wav file: %v", err)
+	}
+	return ReadWAV(data)
+}
+
+// WAV 将音频封装为16位PCM的WAV数据
+func (a *PCMAudio) WAV() []byte {
+	pcm := a.Bytes()
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(36+len(pcm)))
+	copy(header[8:12], "WAVE")
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], 1)
+	binary.LittleEndian.PutUint16(header[22:24], uint16(a.Channels))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(a.SampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(a.SampleRate*a.Channels*2))
+	binary.LittleEndian.PutUint16(header[32:34], uint16(a.Channels*2))
+	binary.LittleEndian.PutUint16(header[34:36], 16)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(len(pcm)))
+	return append(header, pcm...)
+}
+
+// WriteWAVFile 将音频写入WAV文件
+func (a *PCMAudio) WriteWAVFile(path string) error {
+	if err := os.WriteFile(path, a.WAV(), 0644); err != nil {
+		return fmt.Errorf("write output file failed: %v", err)
+	}
+	return nil
+}
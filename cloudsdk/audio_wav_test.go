@@ -0,0 +1,88 @@
+package cloudsdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAVRoundTrip(t *testing.T) {
+	stereo := &PCMAudio{SampleRate: 8000, Channels: 2, Samples: []int16{1, -1, 300, -300, 32767, -32768}}
+	parsed, err := ReadWAV(stereo.WAV())
+	require.NoError(t, err)
+	assert.Equal(t, stereo, parsed)
+	assert.Equal(t, 3, parsed.Frames())
+
+	_, err = ReadWAV([]byte("RIFF0000WAVE"))
+	assert.Error(t, err)
+}
+
+// wavChunk 构造一个RIFF块，奇数长度时补齐填充字节
+func wavChunk(id string, body []byte) []byte {
+	chunk := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	chunk = append(chunk, body...)
+	if len(body)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// extensibleWAV 构造一个WAVE_FORMAT_EXTENSIBLE格式、data前后带有额外块的WAV
+func extensibleWAV(a *PCMAudio, subFormat uint16) []byte {
+	fmtBody := binary.LittleEndian.AppendUint16(nil, wavFormatExtensible)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, uint16(a.Channels))
+	fmtBody = binary.LittleEndian.AppendUint32(fmtBody, uint32(a.SampleRate))
+	fmtBody = binary.LittleEndian.AppendUint32(fmtBody, uint32(a.SampleRate*a.Channels*2))
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, uint16(a.Channels*2))
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, 16)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, 22)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, 16)
+	fmtBody = binary.LittleEndian.AppendUint32(fmtBody, 0x4)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, subFormat)
+	fmtBody = append(fmtBody, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71)
+
+	body := []byte("WAVE")
+	body = append(body, wavChunk("fmt ", fmtBody)...)
+	body = append(body, wavChunk("LIST", []byte("odd"))...)
+	body = append(body, wavChunk("data", a.Bytes())...)
+	body = append(body, wavChunk("id3 ", []byte("trailer"))...)
+	return append([]byte("RIFF"), append(binary.LittleEndian.AppendUint32(nil, uint32(len(body))), body...)...)
+}
+
+func TestReadWAV_Extensible(t *testing.T) {
+	a := &PCMAudio{SampleRate: 8000, Channels: 1, Samples: []int16{1, 2, 3, -4, 5}}
+	data := extensibleWAV(a, wavFormatPCM)
+
+	r := bytes.NewReader(data)
+	format, err := ReadWAVHeader(r)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(wavFormatPCM), format.FormatTag)
+	assert.Equal(t, int64(10), format.DataSize)
+
+	parsed, err := ReadWAV(data)
+	require.NoError(t, err)
+	assert.Equal(t, a, parsed)
+
+	_, err = ReadWAV(extensibleWAV(a, wavFormatIEEEFloat))
+	assert.ErrorContains(t, err, "IEEE float")
+}
+
+func TestReadWAVHeader_Malformed(t *testing.T) {
+	// fmt块声明4GiB长度，不应按该长度分配内存
+	huge := append([]byte("RIFF\x00\x00\x00\x00WAVEfmt "), 0xfe, 0xff, 0xff, 0xff)
+	_, err := ReadWAVHeader(bytes.NewReader(huge))
+	assert.ErrorContains(t, err, "fmt chunk size")
+
+	// 长度为0的data块就是空的，后面的块不能当作音频读出
+	a := &PCMAudio{SampleRate: 8000, Channels: 1}
+	fmtBody := a.WAV()[20:36]
+	body := append([]byte("WAVE"), wavChunk("fmt ", fmtBody)...)
+	body = append(body, wavChunk("data", nil)...)
+	body = append(body, wavChunk("LIST", []byte("INFOISFT"))...)
+	parsed, err := ReadWAV(wavChunk("RIFF", body))
+	require.NoError(t, err)
+	assert.Empty(t, parsed.Samples)
+}
@@ -0,0 +1,83 @@
+// asreval 在评估清单上运行bigmodel流式识别，输出CER/WER和对齐详情
+//
+// 用法:
+//
+//	VOLC_APP_KEY=... VOLC_ACCESS_KEY=... asreval -manifest testset.jsonl -hotwords hotwords.txt -report report.json -v
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/shikanon/myapi/cloudsdk"
+	"github.com/shikanon/myapi/cloudsdk/asreval"
+)
+
+func main() {
+	manifest := flag.String("manifest", "", "评估清单，每行一条JSON或以TAB分隔的音频路径和参考文本")
+	wsURL := flag.String("url", "wss://openspeech.bytedance.com/api/v3/sauc/bigmodel", "识别服务地址")
+	segDuration := flag.Int("seg", 100, "分包时长，毫秒")
+	hotWords := flag.String("hotwords", "", "热词文件，每行一个")
+	concurrency := flag.Int("concurrency", 4, "并发识别数")
+	keepPunc := flag.Bool("keep-punc", false, "计算错误率时保留标点")
+	report := flag.String("report", "", "JSON报告的输出路径")
+	verbose := flag.Bool("v", false, "输出每条的对齐详情")
+	flag.Parse()
+	if *manifest == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	items, err := asreval.LoadManifest(*manifest)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config := &cloudsdk.AsrConfig{
+		SegDuration: *segDuration,
+		WsURL:       *wsURL,
+		UID:         "asreval",
+		Format:      "wav",
+		Rate:        16000,
+		Bits:        16,
+		Channel:     1,
+		Codec:       "raw",
+		AccessKey:   os.Getenv("VOLC_ACCESS_KEY"),
+		AppKey:      os.Getenv("VOLC_APP_KEY"),
+	}
+	if *hotWords != "" {
+		words, err := cloudsdk.LoadHotWords(*hotWords)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.HotWordList = words
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	evaluator := &asreval.Evaluator{
+		Recognizer:  cloudsdk.NewAsrWsClient(config),
+		Concurrency: *concurrency,
+		Normalize:   asreval.NormalizeOptions{KeepPunctuation: *keepPunc},
+	}
+	result, err := evaluator.Run(ctx, items)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := result.WriteText(os.Stdout, *verbose); err != nil {
+		log.Fatal(err)
+	}
+	if *report != "" {
+		f, err := os.Create(*report)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		if err := result.WriteJSON(f); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -22,13 +23,15 @@ func TestReadBook(t *testing.T) {
 		t.Errorf("Expected error for nonexistent file, got nil")
 	}
 
-	// 读取空文件测试
-	emptyFile, err := os.Create("empty.txt")
+	// 读取空文件测试，临时文件写入t.TempDir()，不在包目录中留下文件
+	dir := t.TempDir()
+	emptyPath := filepath.Join(dir, "empty.txt")
+	emptyFile, err := os.Create(emptyPath)
 	if err != nil {
 		t.Fatalf("Error creating empty file: %v", err)
 	}
 	emptyFile.Close()
-	content, err = ReadBook("empty.txt")
+	content, err = ReadBook(emptyPath)
 	if err != nil {
 		t.Errorf("Expected no error for empty file, got %v", err)
 	}
@@ -37,7 +40,8 @@ func TestReadBook(t *testing.T) {
 	}
 
 	// 编码错误测试
-	wrongEncodingFile, err := os.Create("wrong_encoding.txt")
+	wrongEncodingPath := filepath.Join(dir, "wrong_encoding.txt")
+	wrongEncodingFile, err := os.Create(wrongEncodingPath)
 	if err != nil {
 		t.Fatalf("Error creating wrong encoding file: %v", err)
 	}
@@ -46,7 +50,7 @@ func TestReadBook(t *testing.T) {
 		t.Fatalf("Error writing to wrong encoding file: %v", err)
 	}
 	wrongEncodingFile.Close()
-	_, err = ReadBook("wrong_encoding.txt")
+	_, err = ReadBook(wrongEncodingPath)
 	if err == nil {
 		t.Errorf("Expected error for wrong encoding, got nil")
 	}